@@ -1,7 +1,10 @@
 package ast
 
+import "strings"
+
 type Node interface {
-	String() string
+	String() string // ソースに近い形の表示(演算の結合が分かるように式は括弧で囲む)
+	Line() int      // ノードが始まる行番号
 }
 
 type Statement interface {
@@ -19,7 +22,21 @@ type Program struct {
 }
 
 func (p Program) String() string {
-	return ""
+	var out strings.Builder
+	for i, s := range p.Statements {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(s.String())
+	}
+	return out.String()
+}
+
+func (p *Program) Line() int {
+	if len(p.Statements) == 0 {
+		return 0
+	}
+	return p.Statements[0].Line()
 }
 
 func (p *Program) ParseStatement() {
@@ -27,3 +44,19 @@ func (p *Program) ParseStatement() {
 		s.statementNode()
 	}
 }
+
+// indent は s の各行を字下げする
+func indent(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
+
+// braces は省略できるブロックを表示する
+func braces(block *BlockStatement) string {
+	if block == nil {
+		return "{}"
+	}
+	return block.String()
+}
@@ -1,17 +1,29 @@
 package ast
 
 import (
+	"strconv"
+	"strings"
+
 	"go-interpreter-practice/token"
 )
 
 type Identifier struct {
 	Token token.Token
 	Value string
+
+	// resolver が設定する変数の位置
+	// Resolved なら Depth 個外側のスコープの Slot 番目(負ならグローバル)にある
+	Resolved bool
+	Depth    int
+	Slot     int
 }
 
 func (i *Identifier) expressionNode() {}
+func (i *Identifier) Line() int {
+	return i.Token.Line
+}
 func (i *Identifier) String() string {
-	return ""
+	return i.Value
 }
 
 type IntegerLiteral struct {
@@ -20,8 +32,11 @@ type IntegerLiteral struct {
 }
 
 func (il *IntegerLiteral) expressionNode() {}
+func (il *IntegerLiteral) Line() int {
+	return il.Token.Line
+}
 func (il *IntegerLiteral) String() string {
-	return ""
+	return strconv.Itoa(il.Value)
 }
 
 type FloatLiteral struct {
@@ -30,8 +45,16 @@ type FloatLiteral struct {
 }
 
 func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) Line() int {
+	return fl.Token.Line
+}
 func (fl *FloatLiteral) String() string {
-	return ""
+	s := strconv.FormatFloat(fl.Value, 'f', -1, 64)
+	// 整数と区別できるように小数点を付ける
+	if !strings.ContainsAny(s, ".IN") {
+		s += ".0"
+	}
+	return s
 }
 
 type PrefixExpression struct {
@@ -41,8 +64,11 @@ type PrefixExpression struct {
 }
 
 func (pe *PrefixExpression) expressionNode() {}
+func (pe *PrefixExpression) Line() int {
+	return pe.Token.Line
+}
 func (pe *PrefixExpression) String() string {
-	return ""
+	return "(" + pe.Operator + pe.Right.String() + ")"
 }
 
 type InfixExpression struct {
@@ -53,8 +79,11 @@ type InfixExpression struct {
 }
 
 func (ie *InfixExpression) expressionNode() {}
+func (ie *InfixExpression) Line() int {
+	return ie.Token.Line
+}
 func (ie *InfixExpression) String() string {
-	return ""
+	return "(" + ie.Left.String() + " " + ie.Operator + " " + ie.Right.String() + ")"
 }
 
 type Boolean struct {
@@ -63,8 +92,11 @@ type Boolean struct {
 }
 
 func (b *Boolean) expressionNode() {}
+func (b *Boolean) Line() int {
+	return b.Token.Line
+}
 func (b *Boolean) String() string {
-	return ""
+	return strconv.FormatBool(b.Value)
 }
 
 type StringLiteral struct {
@@ -73,8 +105,11 @@ type StringLiteral struct {
 }
 
 func (sl *StringLiteral) expressionNode() {}
+func (sl *StringLiteral) Line() int {
+	return sl.Token.Line
+}
 func (sl *StringLiteral) String() string {
-	return ""
+	return `"` + sl.Value + `"`
 }
 
 type IfExpression struct {
@@ -85,29 +120,193 @@ type IfExpression struct {
 }
 
 func (ie *IfExpression) expressionNode() {}
+func (ie *IfExpression) Line() int {
+	return ie.Token.Line
+}
 func (ie *IfExpression) String() string {
-	return ""
+	s := "if (" + ie.Condition.String() + ") " + braces(ie.Consequence)
+	if ie.Alternative != nil {
+		s += " else " + braces(ie.Alternative)
+	}
+	return s
 }
 
 type FunctionExpression struct {
 	Token      token.Token
 	Name       *Identifier
 	Parameters []*Identifier
+	Defaults   []Expression // Parameters と同じ長さで、デフォルト値がない引数は nil
+	Rest       *Identifier  // func(first, ...rest) の rest
 	Body       *BlockStatement
+	Locals     []string // resolver が設定する、引数と本体のローカル変数名(slot の順)
 }
 
 func (fe *FunctionExpression) expressionNode() {}
+func (fe *FunctionExpression) Line() int {
+	return fe.Token.Line
+}
 func (fe *FunctionExpression) String() string {
-	return ""
+	s := "func"
+	if fe.Name != nil {
+		s += " " + fe.Name.Value
+	}
+	return s + fe.Signature()
+}
+
+// Signature は引数と本体を (a, b = 1, ...rest) { ... } の形で返す
+// メソッドの表示にも使う
+func (fe *FunctionExpression) Signature() string {
+	params := make([]string, 0, len(fe.Parameters)+1)
+	for i, param := range fe.Parameters {
+		if i < len(fe.Defaults) && fe.Defaults[i] != nil {
+			params = append(params, param.Value+" = "+fe.Defaults[i].String())
+		} else {
+			params = append(params, param.Value)
+		}
+	}
+	if fe.Rest != nil {
+		params = append(params, "..."+fe.Rest.Value)
+	}
+	return "(" + strings.Join(params, ", ") + ") " + braces(fe.Body)
 }
 
 type CallExpression struct {
 	Token     token.Token
 	Function  *Expression
 	Arguments []*Expression
+	Tail      bool // 関数の末尾位置にある呼び出しかどうか
 }
 
 func (ce *CallExpression) expressionNode() {}
+func (ce *CallExpression) Line() int {
+	return ce.Token.Line
+}
 func (ce *CallExpression) String() string {
-	return ""
+	args := make([]string, len(ce.Arguments))
+	for i, arg := range ce.Arguments {
+		args[i] = (*arg).String()
+	}
+	return (*ce.Function).String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+type HashLiteral struct {
+	Token token.Token
+	Pairs []*HashPair // ソース上の順番を保持する
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (hl *HashLiteral) expressionNode() {}
+func (hl *HashLiteral) Line() int {
+	return hl.Token.Line
+}
+func (hl *HashLiteral) String() string {
+	pairs := make([]string, len(hl.Pairs))
+	for i, pair := range hl.Pairs {
+		pairs[i] = pair.Key.String() + ": " + pair.Value.String()
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+type IndexExpression struct {
+	Token token.Token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode() {}
+func (ie *IndexExpression) Line() int {
+	return ie.Token.Line
+}
+func (ie *IndexExpression) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// target = value
+// target には Identifier か IndexExpression か GetExpression が入る
+type AssignExpression struct {
+	Token  token.Token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) Line() int {
+	return ae.Token.Line
+}
+func (ae *AssignExpression) String() string {
+	return ae.Target.String() + " = " + ae.Value.String()
+}
+
+// object.name
+type GetExpression struct {
+	Token  token.Token
+	Object Expression
+	Name   *Identifier
+}
+
+func (ge *GetExpression) expressionNode() {}
+func (ge *GetExpression) Line() int {
+	return ge.Token.Line
+}
+func (ge *GetExpression) String() string {
+	return ge.Object.String() + "." + ge.Name.Value
+}
+
+type ThisExpression struct {
+	Token token.Token
+}
+
+func (te *ThisExpression) expressionNode() {}
+func (te *ThisExpression) Line() int {
+	return te.Token.Line
+}
+func (te *ThisExpression) String() string {
+	return "this"
+}
+
+// super.method
+type SuperExpression struct {
+	Token  token.Token
+	Method *Identifier
+}
+
+func (se *SuperExpression) expressionNode() {}
+func (se *SuperExpression) Line() int {
+	return se.Token.Line
+}
+func (se *SuperExpression) String() string {
+	return "super." + se.Method.Value
+}
+
+type NilLiteral struct {
+	Token token.Token
+}
+
+func (nl *NilLiteral) expressionNode() {}
+func (nl *NilLiteral) Line() int {
+	return nl.Token.Line
+}
+func (nl *NilLiteral) String() string {
+	return "nil"
+}
+
+type ArrayLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+func (al *ArrayLiteral) Line() int {
+	return al.Token.Line
+}
+func (al *ArrayLiteral) String() string {
+	elements := make([]string, len(al.Elements))
+	for i, element := range al.Elements {
+		elements[i] = element.String()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
 }
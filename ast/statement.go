@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"strings"
+
 	"go-interpreter-practice/token"
 )
 
@@ -11,8 +13,11 @@ type VarStatement struct {
 }
 
 func (vs *VarStatement) statementNode() {}
+func (vs *VarStatement) Line() int {
+	return vs.Token.Line
+}
 func (vs *VarStatement) String() string {
-	return ""
+	return "var " + vs.Name.Value + " = " + vs.Value.String()
 }
 
 type ReturnStatement struct {
@@ -21,8 +26,11 @@ type ReturnStatement struct {
 }
 
 func (es *ReturnStatement) statementNode() {}
+func (es *ReturnStatement) Line() int {
+	return es.Token.Line
+}
 func (rs *ReturnStatement) String() string {
-	return ""
+	return "return " + rs.ReturnValue.String()
 }
 
 type ExpressionStatement struct {
@@ -31,16 +39,107 @@ type ExpressionStatement struct {
 }
 
 func (es *ExpressionStatement) statementNode() {}
+func (es *ExpressionStatement) Line() int {
+	return es.Token.Line
+}
 func (es *ExpressionStatement) String() string {
-	return ""
+	if es.Expression == nil {
+		return ""
+	}
+	return es.Expression.String()
 }
 
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	Locals     []string // resolver が設定する、このブロックのローカル変数名(slot の順)
 }
 
 func (bs *BlockStatement) statementNode() {}
+func (bs *BlockStatement) Line() int {
+	return bs.Token.Line
+}
 func (bs *BlockStatement) String() string {
-	return ""
+	if len(bs.Statements) == 0 {
+		return "{}"
+	}
+	statements := make([]string, len(bs.Statements))
+	for i, s := range bs.Statements {
+		statements[i] = s.String()
+	}
+	return "{\n" + indent(strings.Join(statements, "\n")) + "\n}"
+}
+
+// for (key in iterable) { ... }
+type ForStatement struct {
+	Token    token.Token
+	Key      *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+	Locals   []string // resolver が設定する、ループ変数と本体のローカル変数名(slot の順)
+}
+
+func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) Line() int {
+	return fs.Token.Line
+}
+func (fs *ForStatement) String() string {
+	return "for (" + fs.Key.Value + " in " + fs.Iterable.String() + ") " + braces(fs.Body)
+}
+
+// class Name < Superclass { method() { ... } }
+type ClassStatement struct {
+	Token      token.Token
+	Name       *Identifier
+	Superclass *Identifier
+	Methods    []*FunctionExpression
+}
+
+func (cs *ClassStatement) statementNode() {}
+func (cs *ClassStatement) Line() int {
+	return cs.Token.Line
+}
+func (cs *ClassStatement) String() string {
+	s := "class " + cs.Name.Value
+	if cs.Superclass != nil {
+		s += " < " + cs.Superclass.Value
+	}
+	if len(cs.Methods) == 0 {
+		return s + " {}"
+	}
+	methods := make([]string, len(cs.Methods))
+	for i, method := range cs.Methods {
+		methods[i] = method.Name.Value + method.Signature()
+	}
+	return s + " {\n" + indent(strings.Join(methods, "\n")) + "\n}"
+}
+
+// print expression
+type PrintStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ps *PrintStatement) statementNode() {}
+func (ps *PrintStatement) Line() int {
+	return ps.Token.Line
+}
+func (ps *PrintStatement) String() string {
+	return "print " + ps.Value.String()
+}
+
+// func name(params) { body }
+// 文の位置に書かれた名前付き関数は、その名前をスコープに宣言する
+type FunctionStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Function *FunctionExpression
+}
+
+func (fs *FunctionStatement) statementNode() {}
+func (fs *FunctionStatement) Line() int {
+	return fs.Token.Line
+}
+func (fs *FunctionStatement) String() string {
+	return fs.Function.String()
 }
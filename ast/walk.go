@@ -0,0 +1,106 @@
+package ast
+
+// Inspect は node から深さ優先で木をたどり、各ノードで f を呼ぶ
+// f が false を返したノードの子はたどらない
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *BlockStatement:
+		if n == nil {
+			return
+		}
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ExpressionStatement:
+		inspectExpression(n.Expression, f)
+	case *VarStatement:
+		Inspect(n.Name, f)
+		inspectExpression(n.Value, f)
+	case *ReturnStatement:
+		inspectExpression(n.ReturnValue, f)
+	case *PrintStatement:
+		inspectExpression(n.Value, f)
+	case *FunctionStatement:
+		Inspect(n.Function, f)
+	case *ForStatement:
+		Inspect(n.Key, f)
+		inspectExpression(n.Iterable, f)
+		inspectBlock(n.Body, f)
+	case *ClassStatement:
+		Inspect(n.Name, f)
+		if n.Superclass != nil {
+			Inspect(n.Superclass, f)
+		}
+		for _, method := range n.Methods {
+			Inspect(method, f)
+		}
+	case *PrefixExpression:
+		inspectExpression(n.Right, f)
+	case *InfixExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	case *IfExpression:
+		inspectExpression(n.Condition, f)
+		inspectBlock(n.Consequence, f)
+		inspectBlock(n.Alternative, f)
+	case *FunctionExpression:
+		if n.Name != nil {
+			Inspect(n.Name, f)
+		}
+		for i, param := range n.Parameters {
+			Inspect(param, f)
+			if i < len(n.Defaults) {
+				inspectExpression(n.Defaults[i], f)
+			}
+		}
+		if n.Rest != nil {
+			Inspect(n.Rest, f)
+		}
+		inspectBlock(n.Body, f)
+	case *CallExpression:
+		inspectExpression(*n.Function, f)
+		for _, arg := range n.Arguments {
+			inspectExpression(*arg, f)
+		}
+	case *ArrayLiteral:
+		for _, element := range n.Elements {
+			inspectExpression(element, f)
+		}
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			inspectExpression(pair.Key, f)
+			inspectExpression(pair.Value, f)
+		}
+	case *IndexExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Index, f)
+	case *AssignExpression:
+		inspectExpression(n.Target, f)
+		inspectExpression(n.Value, f)
+	case *GetExpression:
+		inspectExpression(n.Object, f)
+		Inspect(n.Name, f)
+	case *SuperExpression:
+		Inspect(n.Method, f)
+	}
+}
+
+// 省略された式(else のない if の Alternative など)は飛ばす
+func inspectExpression(e Expression, f func(Node) bool) {
+	if e != nil {
+		Inspect(e, f)
+	}
+}
+
+func inspectBlock(b *BlockStatement, f func(Node) bool) {
+	if b != nil {
+		Inspect(b, f)
+	}
+}
@@ -0,0 +1,298 @@
+// Package bind は Go のパッケージを読み、そのエクスポートされた関数・型・定数を
+// onu のモジュールとして登録するラッパーのコードを生成する
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Generate は importPath のパッケージのラッパーを pkgName パッケージのコードとして生成する
+// 生成したコードは同じパッケージにある補助関数(checkArity, argString など)を使う
+// 変換できない型を引数や戻り値に持つ関数は飛ばし、生成したコードのコメントに残す
+func Generate(importPath, pkgName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkg, err := importer.ForCompiler(fset, "source", nil).Import(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: map[string]string{importPath: pkg.Name()}}
+	var body bytes.Buffer
+	g.out = &body
+	g.generateModule()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by \"onu bind %s\"; DO NOT EDIT.\n\n", importPath)
+	fmt.Fprintf(&out, "package %s\n\n", pkgName)
+	out.WriteString("import (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString("\n\t\"go-interpreter-practice/object\"\n)\n\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // import path -> パッケージ名
+	out     *bytes.Buffer
+	skipped []string
+}
+
+func (g *generator) printf(format string, a ...interface{}) {
+	fmt.Fprintf(g.out, format, a...)
+}
+
+func (g *generator) generateModule() {
+	name := g.pkg.Name()
+	funcName := name + "Module"
+	g.printf("func init() {\n\tmodules = append(modules, %s)\n}\n\n", funcName)
+	g.printf("// %s は Go の %s パッケージを onu の %s モジュールにする\n", funcName, g.pkg.Path(), name)
+	g.printf("func %s() *object.Module {\n", funcName)
+	g.printf("m := object.NewModule(%q)\n", name)
+
+	scope := g.pkg.Scope()
+	for _, member := range scope.Names() {
+		obj := scope.Lookup(member)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			g.generateConst(obj)
+		case *types.Func:
+			g.generateFunc(obj)
+		case *types.TypeName:
+			g.generateType(obj)
+		}
+	}
+	g.printf("return m\n}\n")
+
+	if len(g.skipped) > 0 {
+		g.printf("\n// 変換できない型や値を使うため、次の関数と定数は登録していない\n")
+		for _, s := range g.skipped {
+			g.printf("//   - %s\n", s)
+		}
+	}
+}
+
+func (g *generator) qualified(name string) string {
+	return g.pkg.Name() + "." + name
+}
+
+func (g *generator) generateConst(c *types.Const) {
+	name := g.qualified(c.Name())
+	switch c.Val().Kind() {
+	case constant.Bool:
+		g.printf("m.Members[%q] = object.NewBoolean(bool(%s))\n", c.Name(), name)
+	case constant.String:
+		g.printf("m.Members[%q] = object.NewString(string(%s))\n", c.Name(), name)
+	case constant.Int:
+		// int に収まらない定数(math.MaxUint64 など)は表せない
+		if _, exact := constant.Int64Val(c.Val()); !exact {
+			g.skipped = append(g.skipped, name)
+			return
+		}
+		g.printf("m.Members[%q] = object.NewInteger(int(%s))\n", c.Name(), name)
+	case constant.Float:
+		g.printf("m.Members[%q] = object.NewFloat(float64(%s))\n", c.Name(), name)
+	}
+}
+
+// generateType は構造体型のゼロ値を作る関数を登録する
+// 作った値はポインタとして渡すので、スクリプトからメソッドを呼べる
+func (g *generator) generateType(t *types.TypeName) {
+	if t.IsAlias() {
+		return
+	}
+	named, ok := t.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return
+	}
+	g.printf("m.Members[%q] = newType(%q, func() interface{} { return new(%s) })\n",
+		t.Name(), g.qualified(t.Name()), g.qualified(t.Name()))
+}
+
+func (g *generator) generateFunc(f *types.Func) {
+	name := g.qualified(f.Name())
+	sig := f.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || !g.supportedTuple(sig.Params()) || !g.supportedTuple(sig.Results()) {
+		g.skipped = append(g.skipped, name)
+		return
+	}
+
+	params := sig.Params()
+	fixed := params.Len()
+	if sig.Variadic() {
+		fixed--
+	}
+	g.printf("m.Members[%q] = &object.Builtin{Name: %q, Fn: func(args ...object.Object) object.Object {\n", f.Name(), name)
+	if sig.Variadic() {
+		g.printf("if err := checkVariadicArity(%q, args, %d); err != nil {\nreturn err\n}\n", name, fixed)
+	} else {
+		g.printf("if err := checkArity(%q, args, %d); err != nil {\nreturn err\n}\n", name, fixed)
+	}
+
+	callArgs := make([]string, params.Len())
+	for i := 0; i < fixed; i++ {
+		callArgs[i] = g.generateArg(name, i, params.At(i).Type())
+	}
+	if sig.Variadic() {
+		elem := params.At(fixed).Type().(*types.Slice).Elem()
+		v := fmt.Sprintf("a%d", fixed)
+		g.printf("%s := make([]%s, len(args)-%d)\n", v, g.typeString(elem), fixed)
+		g.printf("for i := range %s {\nif err := argValue(%q, args, %d+i, &%s[i]); err != nil {\nreturn err\n}\n}\n", v, name, fixed, v)
+		callArgs[fixed] = v + "..."
+	}
+
+	g.generateCall(name, sig.Results(), strings.Join(callArgs, ", "))
+	g.printf("}}\n")
+}
+
+// generateArg は i 番目の引数を Go の値に変換するコードを書き、変数名を返す
+// よく使う型は型アサーションで直接変換し、それ以外は FromObject に任せる
+func (g *generator) generateArg(name string, i int, t types.Type) string {
+	v := fmt.Sprintf("a%d", i)
+	helper := ""
+	if basic, ok := t.(*types.Basic); ok {
+		switch basic.Kind() {
+		case types.String:
+			helper = "argString"
+		case types.Bool:
+			helper = "argBool"
+		case types.Int:
+			helper = "argInt"
+		case types.Float64:
+			helper = "argFloat"
+		}
+	}
+	if helper != "" {
+		g.printf("%s, err := %s(%q, args, %d)\nif err != nil {\nreturn err\n}\n", v, helper, name, i)
+		return v
+	}
+	g.printf("var %s %s\nif err := argValue(%q, args, %d, &%s); err != nil {\nreturn err\n}\n", v, g.typeString(t), name, i, v)
+	return v
+}
+
+// generateCall は関数を呼び出し、戻り値をオブジェクトにして返すコードを書く
+// 最後の戻り値が error なら、nil でないときに実行時エラーにする
+func (g *generator) generateCall(name string, results *types.Tuple, args string) {
+	n := results.Len()
+	hasError := n > 0 && types.Identical(results.At(n-1).Type(), types.Universe.Lookup("error").Type())
+	values := make([]string, 0, n)
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if hasError && i == n-1 {
+			names = append(names, "goErr")
+			continue
+		}
+		v := fmt.Sprintf("r%d", i)
+		values = append(values, v)
+		names = append(names, v)
+	}
+
+	call := fmt.Sprintf("%s(%s)", name, args)
+	if n == 0 {
+		g.printf("%s\nreturn object.NewNil()\n", call)
+		return
+	}
+	g.printf("%s := %s\n", strings.Join(names, ", "), call)
+	if hasError {
+		g.printf("if goErr != nil {\nreturn goError(%q, goErr)\n}\n", name)
+	}
+	switch len(values) {
+	case 0:
+		g.printf("return object.NewNil()\n")
+	case 1:
+		g.printf("return %s\n", g.resultObject(name, results.At(0).Type(), values[0]))
+	default:
+		g.printf("return toObjects(%q, %s)\n", name, strings.Join(values, ", "))
+	}
+}
+
+func (g *generator) resultObject(name string, t types.Type, v string) string {
+	if basic, ok := t.(*types.Basic); ok {
+		switch basic.Kind() {
+		case types.String:
+			return "object.NewString(" + v + ")"
+		case types.Bool:
+			return "object.NewBoolean(" + v + ")"
+		case types.Int:
+			return "object.NewInteger(" + v + ")"
+		case types.Float64:
+			return "object.NewFloat(" + v + ")"
+		}
+	}
+	return fmt.Sprintf("toObject(%q, %s)", name, v)
+}
+
+func (g *generator) supportedTuple(tuple *types.Tuple) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if !g.supported(tuple.At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// supported は t の値を onu のオブジェクトと相互に変換でき、生成したコードから型名を書けるかを返す
+func (g *generator) supported(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() != types.UnsafePointer && t.Info()&types.IsUntyped == 0
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return true // error
+		}
+		if !obj.Exported() || strings.Contains(obj.Pkg().Path(), "internal") || t.TypeArgs().Len() > 0 {
+			return false
+		}
+		switch t.Underlying().(type) {
+		case *types.Signature, *types.Chan:
+			return false
+		}
+		return true
+	case *types.Pointer:
+		return g.supported(t.Elem())
+	case *types.Slice:
+		return g.supported(t.Elem())
+	case *types.Array:
+		return g.supported(t.Elem())
+	case *types.Map:
+		return g.supported(t.Key()) && g.supported(t.Elem())
+	case *types.Interface:
+		return t.Empty()
+	}
+	return false
+}
+
+// typeString は生成するコードの中での型名を返し、必要な import を記録する
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
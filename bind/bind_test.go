@@ -0,0 +1,49 @@
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stdlib/ に置いた生成済みのコードが、今の生成器の出力と一致するかを確かめる
+// Go のバージョンを上げて標準パッケージの API が変わったときも失敗するので、
+// そのときは stdlib で go generate を実行し直す
+func TestGenerateMatchesStdlib(t *testing.T) {
+	for _, importPath := range []string{"math", "strconv", "strings"} {
+		t.Run(importPath, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("..", "stdlib", "bind_"+importPath+".go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Generate(importPath, "stdlib")
+			if err != nil {
+				t.Fatalf("Generate(%q): %v", importPath, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("stdlib/bind_%s.go is out of date; run go generate ./stdlib\n%s", importPath, firstDiff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+// firstDiff は最初に食い違う行を返す
+func firstDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
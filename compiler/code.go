@@ -0,0 +1,218 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Instructions は命令列
+// 各命令は 1 バイトの Opcode と、定義された幅のオペランド(ビッグエンディアン)からなる
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota // 定数プールの値を積む
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpEqual
+	OpNotEqual
+	OpLess
+	OpLessEqual
+	OpGreater
+	OpGreaterEqual
+	OpMinus
+	OpNot
+
+	OpJump
+	OpJumpIfFalse    // 条件を取り除き、偽ならジャンプする
+	OpJumpIfProvided // 引数が渡されていればデフォルト値の計算を飛ばす
+
+	OpGetGlobal    // グローバル変数(なければ組み込み関数)を名前で探す
+	OpSetGlobal    // 代入した値はスタックに残す
+	OpDefineGlobal // 値を取り除いてグローバル変数を宣言する
+	OpGetLocal
+	OpSetLocal // 代入した値はスタックに残す
+	OpGetUpvalue
+	OpSetUpvalue
+	OpCloseUpvalues // slot 以降のローカル変数を捕まえている upvalue を閉じる
+
+	OpArray
+	OpHash
+	OpIndex
+	OpSetIndex // value, left, index を取り除いて value を積む
+	OpGetProperty
+	OpSetProperty // value, object を取り除いて value を積む
+	OpGetSuper    // this, superclass を取り除いて束縛したメソッドを積む
+
+	OpCall
+	OpTailCall // 関数なら今のフレームを置き換え、それ以外は OpCall と同じ
+	OpReturn
+	OpClosure
+	OpClass  // スーパークラスがあればそれを取り除き、クラスを積む
+	OpMethod // クロージャを取り除き、その下のクラスにメソッドとして加える
+
+	OpIter     // 値を取り除き、要素を順に取り出すイテレータを積む
+	OpIterNext // 次の要素をローカル変数に入れる。なくなればイテレータを取り除いてジャンプする
+	OpPrint
+)
+
+// Definition は命令の名前とオペランドのバイト数
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpNil:      {"OpNil", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreater:      {"OpGreater", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpNot:          {"OpNot", []int{}},
+
+	OpJump:           {"OpJump", []int{2}},
+	OpJumpIfFalse:    {"OpJumpIfFalse", []int{2}},
+	OpJumpIfProvided: {"OpJumpIfProvided", []int{2, 2}}, // slot, ジャンプ先
+
+	OpGetGlobal:     {"OpGetGlobal", []int{2}}, // 名前の定数
+	OpSetGlobal:     {"OpSetGlobal", []int{2}},
+	OpDefineGlobal:  {"OpDefineGlobal", []int{2}},
+	OpGetLocal:      {"OpGetLocal", []int{2}},
+	OpSetLocal:      {"OpSetLocal", []int{2}},
+	OpGetUpvalue:    {"OpGetUpvalue", []int{2}},
+	OpSetUpvalue:    {"OpSetUpvalue", []int{2}},
+	OpCloseUpvalues: {"OpCloseUpvalues", []int{2}},
+
+	OpArray:       {"OpArray", []int{2}}, // 要素の数
+	OpHash:        {"OpHash", []int{2}},  // キーと値の組の数
+	OpIndex:       {"OpIndex", []int{}},
+	OpSetIndex:    {"OpSetIndex", []int{}},
+	OpGetProperty: {"OpGetProperty", []int{2}}, // 名前の定数
+	OpSetProperty: {"OpSetProperty", []int{2}},
+	OpGetSuper:    {"OpGetSuper", []int{2}},
+
+	OpCall:     {"OpCall", []int{2}}, // 引数の数
+	OpTailCall: {"OpTailCall", []int{2}},
+	OpReturn:   {"OpReturn", []int{}},
+	OpClosure:  {"OpClosure", []int{2}},  // CompiledFunction の定数
+	OpClass:    {"OpClass", []int{2, 1}}, // 名前の定数, スーパークラスがあれば 1
+	OpMethod:   {"OpMethod", []int{2}},   // 名前の定数
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2, 2}}, // slot, ジャンプ先
+	OpPrint:    {"OpPrint", []int{}},
+}
+
+// Lookup は op の定義を返す
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make は命令を一つ組み立てる
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+	offset := 1
+	for i, o := range operands {
+		switch def.OperandWidths[i] {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += def.OperandWidths[i]
+	}
+	return instruction
+}
+
+// ReadOperands は命令のオペランドを読み、読んだバイト数と一緒に返す
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ins[offset])
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// String は命令列を逆アセンブルする
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			return out.String()
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s", i, def.Name)
+		for _, operand := range operands {
+			fmt.Fprintf(&out, " %d", operand)
+		}
+		out.WriteString("\n")
+		i += 1 + read
+	}
+	return out.String()
+}
+
+// LineInfo は Offset 以降の命令が Line 行目のものであることを表す
+// 行が変わる位置だけを記録する
+type LineInfo struct {
+	Offset int
+	Line   int
+}
+
+// LineAt は offset の命令のソース上の行番号を返す
+func LineAt(lines []LineInfo, offset int) int {
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].Offset > offset })
+	if i == 0 {
+		return 0
+	}
+	return lines[i-1].Line
+}
@@ -0,0 +1,683 @@
+// Package compiler は ast.Program を vm で実行するバイトコードに変換する
+//
+// 変数の置き場所はコンパイル時に決める
+// トップレベルの変数は評価器と同じくグローバル環境(object.Environment)に名前で置き、
+// それ以外は関数のフレームの slot に置く。内側の関数から参照される変数は upvalue として捕まえる
+// スコープの規則は評価器・resolver と同じで、同じブロックの後ろで宣言される変数は飛ばして外側を探す
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+)
+
+// オペランドに入る値の上限(2 バイト)
+const maxOperand = 1<<16 - 1
+
+// 変数の状態
+type state int
+
+const (
+	pending  state = iota // 同じブロックの後ろで宣言される
+	defining              // 初期化式をコンパイルしている最中
+	defined
+)
+
+type symbol struct {
+	slot     int
+	state    state
+	line     int  // 宣言した行
+	captured bool // 内側の関数から upvalue として参照されているか
+}
+
+// scope は評価器が作る object.Environment 一つに対応する
+type scope struct {
+	symbols   map[string]*symbol
+	firstSlot int
+	global    bool // トップレベルのスコープ(変数は名前で置く)
+}
+
+type functionKind int
+
+const (
+	plainFunction   functionKind = iota
+	namedExpression              // 自分の名前が見える名前付きの関数式
+	method
+	initializer
+)
+
+type classKind int
+
+const (
+	noClass classKind = iota
+	plainClass
+	subclass
+)
+
+// funcState はコンパイル中の関数
+type funcState struct {
+	enclosing *funcState
+	fn        *CompiledFunction
+	scopes    []*scope
+	nextSlot  int
+	tailCalls bool // 末尾位置の呼び出しを OpTailCall にするか
+}
+
+type Compiler struct {
+	constants     []object.Object
+	constantIndex map[interface{}]int
+	current       *funcState
+	class         classKind
+	errors        []error
+}
+
+func NewCompiler() *Compiler {
+	return &Compiler{constantIndex: make(map[interface{}]int)}
+}
+
+func (c *Compiler) GetErrors() []error {
+	return c.errors
+}
+
+func (c *Compiler) addError(line int, format string, a ...interface{}) {
+	c.errors = append(c.errors, fmt.Errorf("line %v; %s", line, fmt.Sprintf(format, a...)))
+}
+
+// Compile はプログラム全体をコンパイルする
+// トップレベルの文は引数を取らない関数 Main にまとめ、最後の式文の値を返す
+func (c *Compiler) Compile(program *ast.Program) (*Bytecode, error) {
+	main := &CompiledFunction{Name: "<main>", NumLocals: 1}
+	c.current = &funcState{fn: main, nextSlot: 1}
+	c.current.scopes = []*scope{{symbols: make(map[string]*symbol), global: true}}
+
+	c.compileStatements(program.Statements, true, program.Line())
+	c.emit(program.Line(), OpReturn)
+
+	if len(c.errors) > 0 {
+		messages := make([]string, len(c.errors))
+		for i, err := range c.errors {
+			messages[i] = err.Error()
+		}
+		return nil, errors.New(strings.Join(messages, "\n"))
+	}
+	main.Constants = c.constants
+	for _, constant := range c.constants {
+		if fn, ok := constant.(*CompiledFunction); ok {
+			fn.Constants = c.constants
+		}
+	}
+	return &Bytecode{Main: main, Constants: c.constants}, nil
+}
+
+// emit は命令を追加し、その位置を返す
+func (c *Compiler) emit(line int, op Opcode, operands ...int) int {
+	fn := c.current.fn
+	position := len(fn.Instructions)
+	for _, operand := range operands {
+		if operand > maxOperand {
+			c.addError(line, "too many constants, variables or instructions in one function")
+		}
+	}
+	fn.Instructions = append(fn.Instructions, Make(op, operands...)...)
+	if n := len(fn.Lines); n == 0 || fn.Lines[n-1].Line != line {
+		fn.Lines = append(fn.Lines, LineInfo{Offset: position, Line: line})
+	}
+	return position
+}
+
+// emitJump はジャンプ先を後で決める命令を追加する
+func (c *Compiler) emitJump(line int, op Opcode, operands ...int) int {
+	return c.emit(line, op, append(operands, maxOperand)...)
+}
+
+// patchJump は position の命令の最後のオペランドを次の命令の位置にする
+func (c *Compiler) patchJump(position int) {
+	fn := c.current.fn
+	def := definitions[Opcode(fn.Instructions[position])]
+	offset := position + 1
+	for _, w := range def.OperandWidths[:len(def.OperandWidths)-1] {
+		offset += w
+	}
+	target := len(fn.Instructions)
+	if target > maxOperand {
+		c.addError(LineAt(fn.Lines, position), "too many constants, variables or instructions in one function")
+	}
+	fn.Instructions[offset] = byte(target >> 8)
+	fn.Instructions[offset+1] = byte(target)
+}
+
+// addConstant は定数プールに obj を加える
+// 同じ数値や文字列は使い回す
+func (c *Compiler) addConstant(obj object.Object) int {
+	var key interface{}
+	switch obj := obj.(type) {
+	case *object.Integer:
+		key = obj.Value
+	case *object.Float:
+		key = obj.Value
+	case *object.String:
+		key = obj.Value
+	}
+	if key != nil {
+		if i, ok := c.constantIndex[key]; ok {
+			return i
+		}
+		c.constantIndex[key] = len(c.constants)
+	}
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) nameConstant(name string) int {
+	return c.addConstant(object.NewString(name))
+}
+
+func (c *Compiler) currentScope() *scope {
+	return c.current.scopes[len(c.current.scopes)-1]
+}
+
+func (c *Compiler) beginScope() {
+	c.current.scopes = append(c.current.scopes, &scope{symbols: make(map[string]*symbol), firstSlot: c.current.nextSlot})
+}
+
+// endScope はスコープを閉じる
+// スコープの変数を捕まえたクロージャがあれば、抜ける前に upvalue を閉じる
+func (c *Compiler) endScope(line int) {
+	s := c.popScope()
+	if s.hasCaptured() {
+		c.emit(line, OpCloseUpvalues, s.firstSlot)
+	}
+}
+
+func (c *Compiler) popScope() *scope {
+	s := c.currentScope()
+	c.current.scopes = c.current.scopes[:len(c.current.scopes)-1]
+	c.current.nextSlot = s.firstSlot
+	return s
+}
+
+func (s *scope) hasCaptured() bool {
+	for _, sym := range s.symbols {
+		if sym.captured {
+			return true
+		}
+	}
+	return false
+}
+
+// declare は今のスコープに変数を宣言する
+// トップレベルの変数は実行時にグローバル環境で宣言されるので、ここでは何もしない
+func (c *Compiler) declare(name *ast.Identifier, s state) {
+	scope := c.currentScope()
+	if scope.global {
+		return
+	}
+	if sym, ok := scope.symbols[name.Value]; ok {
+		c.addError(name.Line(), "%s is already declared in this scope (line %d)", name.Value, sym.line)
+		return
+	}
+	c.addLocal(name.Value, s, name.Line())
+}
+
+// addLocal は宣言の検査をせずに次の slot に変数を追加する
+func (c *Compiler) addLocal(name string, s state, line int) *symbol {
+	fs := c.current
+	sym := &symbol{slot: fs.nextSlot, state: s, line: line}
+	c.currentScope().symbols[name] = sym
+	fs.nextSlot++
+	if fs.nextSlot > fs.fn.NumLocals {
+		fs.fn.NumLocals = fs.nextSlot
+	}
+	return sym
+}
+
+func (c *Compiler) setState(name *ast.Identifier, s state) {
+	if sym, ok := c.currentScope().symbols[name.Value]; ok && sym.line == name.Line() {
+		sym.state = s
+	}
+}
+
+// defineVariable はスタックの一番上の値を今のスコープの変数 name に入れて取り除く
+func (c *Compiler) defineVariable(name *ast.Identifier) {
+	scope := c.currentScope()
+	if scope.global {
+		c.emit(name.Line(), OpDefineGlobal, c.nameConstant(name.Value))
+		return
+	}
+	sym := scope.symbols[name.Value]
+	c.emit(name.Line(), OpSetLocal, sym.slot)
+	c.emit(name.Line(), OpPop)
+	c.setState(name, defined)
+}
+
+// 評価器と同じ順で文をコンパイルする
+// 関数宣言は巻き上げ、var とクラスは後ろで宣言されることだけを先に記録する
+// keepValue なら最後の式文の値(なければ nil)をスタックに残す
+func (c *Compiler) compileStatements(statements []ast.Statement, keepValue bool, line int) {
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			c.declare(declaration.Name, defined)
+		}
+	}
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.VarStatement:
+			c.declare(statement.Name, pending)
+		case *ast.ClassStatement:
+			c.declare(statement.Name, pending)
+		}
+	}
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			c.compileFunction(declaration.Function, declaration.Name.Value, plainFunction)
+			c.defineVariable(declaration.Name)
+		}
+	}
+	for i, statement := range statements {
+		if expression, ok := statement.(*ast.ExpressionStatement); ok && keepValue && i == len(statements)-1 {
+			c.compileExpression(expression.Expression)
+			return
+		}
+		c.compileStatement(statement)
+		line = statement.Line()
+	}
+	if keepValue {
+		c.emit(line, OpNil)
+	}
+}
+
+func (c *Compiler) compileStatement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case *ast.ExpressionStatement:
+		c.compileExpression(node.Expression)
+		c.emit(node.Line(), OpPop)
+	case *ast.VarStatement:
+		c.setState(node.Name, defining)
+		// var sum = func(...) {...} のような無名関数には変数名を付けておく
+		if fn, ok := node.Value.(*ast.FunctionExpression); ok && fn.Name == nil {
+			c.compileFunction(fn, node.Name.Value, plainFunction)
+		} else {
+			c.compileExpression(node.Value)
+		}
+		c.defineVariable(node.Name)
+	case *ast.ReturnStatement:
+		c.compileExpression(node.ReturnValue)
+		if c.current.fn.IsInitializer {
+			// init は常にインスタンス自身を返す
+			c.emit(node.Line(), OpPop)
+			c.emit(node.Line(), OpGetLocal, 0)
+		}
+		c.emit(node.Line(), OpReturn)
+	case *ast.BlockStatement:
+		c.compileBlock(node, false)
+	case *ast.PrintStatement:
+		c.compileExpression(node.Value)
+		c.emit(node.Line(), OpPrint)
+	case *ast.FunctionStatement:
+		// 関数宣言は compileStatements で巻き上げ済み
+	case *ast.ForStatement:
+		c.compileForStatement(node)
+	case *ast.ClassStatement:
+		c.compileClassStatement(node)
+	}
+}
+
+// ブロックは自分のスコープを持つ
+func (c *Compiler) compileBlock(block *ast.BlockStatement, keepValue bool) {
+	c.beginScope()
+	c.compileStatements(block.Statements, keepValue, block.Line())
+	c.endScope(block.Line())
+}
+
+// ループ変数と本体は繰り返しごとに新しくなるスコープに置く
+// 本体の変数を捕まえたクロージャがあれば、繰り返しの最後に upvalue を閉じる
+func (c *Compiler) compileForStatement(node *ast.ForStatement) {
+	line := node.Line()
+	c.compileExpression(node.Iterable)
+	c.emit(line, OpIter)
+
+	c.beginScope()
+	c.declare(node.Key, defined)
+	key := c.currentScope().symbols[node.Key.Value]
+	loop := len(c.current.fn.Instructions)
+	next := c.emitJump(line, OpIterNext, key.slot)
+	c.compileStatements(node.Body.Statements, false, node.Body.Line())
+	s := c.popScope()
+	if s.hasCaptured() {
+		c.emit(line, OpCloseUpvalues, s.firstSlot)
+	}
+	c.emit(line, OpJump, loop)
+	c.patchJump(next)
+}
+
+func (c *Compiler) compileClassStatement(node *ast.ClassStatement) {
+	enclosing := c.class
+	c.class = plainClass
+	defer func() { c.class = enclosing }()
+
+	line := node.Line()
+	hasSuperclass := 0
+	if node.Superclass != nil {
+		if node.Superclass.Value == node.Name.Value {
+			c.addError(node.Superclass.Line(), "a class can't inherit from itself")
+		}
+		c.class = subclass
+		hasSuperclass = 1
+		// メソッドからは super でスーパークラスを参照できる
+		c.compileIdentifier(node.Superclass)
+		c.beginScope()
+		super := c.addLocal("super", defined, line)
+		c.emit(line, OpSetLocal, super.slot)
+	}
+	c.emit(line, OpClass, c.nameConstant(node.Name.Value), hasSuperclass)
+	for _, m := range node.Methods {
+		kind := method
+		if m.Name.Value == "init" {
+			kind = initializer
+		}
+		c.compileFunction(m, node.Name.Value+"."+m.Name.Value, kind)
+		c.emit(m.Line(), OpMethod, c.nameConstant(m.Name.Value))
+	}
+	if node.Superclass != nil {
+		c.endScope(line)
+	}
+	c.defineVariable(node.Name)
+}
+
+// compileFunction は関数本体を CompiledFunction にコンパイルし、クロージャを作る命令を追加する
+func (c *Compiler) compileFunction(node *ast.FunctionExpression, name string, kind functionKind) {
+	fn := &CompiledFunction{
+		Name:          name,
+		NumLocals:     1,
+		NumParams:     len(node.Parameters),
+		MinArity:      len(node.Parameters),
+		HasRest:       node.Rest != nil,
+		IsInitializer: kind == initializer,
+		Source:        object.FunctionSource(node.Parameters, node.Body),
+	}
+	for i, d := range node.Defaults {
+		if d != nil {
+			fn.MinArity = i
+			break
+		}
+	}
+	fs := &funcState{enclosing: c.current, fn: fn, nextSlot: 1, tailCalls: kind != initializer}
+	c.current = fs
+
+	// slot 0 は呼び出された関数自身(メソッドなら this)
+	self := &scope{symbols: make(map[string]*symbol)}
+	switch kind {
+	case namedExpression:
+		self.symbols[name] = &symbol{slot: 0, state: defined, line: node.Line()}
+	case method, initializer:
+		self.symbols["this"] = &symbol{slot: 0, state: defined, line: node.Line()}
+	}
+	fs.scopes = []*scope{self}
+
+	// 引数と本体は同じスコープ
+	// デフォルト値からはそれより前の引数だけが見える
+	c.beginScope()
+	for _, param := range node.Parameters {
+		c.declare(param, pending)
+	}
+	if node.Rest != nil {
+		c.declare(node.Rest, pending)
+	}
+	for i, param := range node.Parameters {
+		if i < len(node.Defaults) && node.Defaults[i] != nil {
+			slot := c.currentScope().symbols[param.Value].slot
+			skip := c.emitJump(param.Line(), OpJumpIfProvided, slot)
+			c.compileExpression(node.Defaults[i])
+			c.emit(param.Line(), OpSetLocal, slot)
+			c.emit(param.Line(), OpPop)
+			c.patchJump(skip)
+		}
+		c.setState(param, defined)
+	}
+	if node.Rest != nil {
+		c.setState(node.Rest, defined)
+	}
+	c.compileStatements(node.Body.Statements, true, node.Body.Line())
+	if fn.IsInitializer {
+		c.emit(node.Body.Line(), OpPop)
+		c.emit(node.Body.Line(), OpGetLocal, 0)
+	}
+	c.emit(node.Body.Line(), OpReturn)
+
+	c.current = fs.enclosing
+	c.emit(node.Line(), OpClosure, c.addConstant(fn))
+}
+
+func (c *Compiler) compileExpression(expression ast.Expression) {
+	switch node := expression.(type) {
+	case nil:
+		c.emit(0, OpNil)
+	case *ast.IntegerLiteral:
+		c.emit(node.Line(), OpConstant, c.addConstant(object.NewInteger(node.Value)))
+	case *ast.FloatLiteral:
+		c.emit(node.Line(), OpConstant, c.addConstant(object.NewFloat(node.Value)))
+	case *ast.StringLiteral:
+		c.emit(node.Line(), OpConstant, c.addConstant(object.NewString(node.Value)))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(node.Line(), OpTrue)
+		} else {
+			c.emit(node.Line(), OpFalse)
+		}
+	case *ast.NilLiteral:
+		c.emit(node.Line(), OpNil)
+	case *ast.Identifier:
+		c.compileIdentifier(node)
+	case *ast.PrefixExpression:
+		c.compileExpression(node.Right)
+		switch node.Operator {
+		case "-":
+			c.emit(node.Line(), OpMinus)
+		case "!":
+			c.emit(node.Line(), OpNot)
+		default:
+			c.addError(node.Line(), "unknown operator: %s", node.Operator)
+		}
+	case *ast.InfixExpression:
+		c.compileExpression(node.Left)
+		c.compileExpression(node.Right)
+		op, ok := infixOperators[node.Operator]
+		if !ok {
+			c.addError(node.Line(), "unknown operator: %s", node.Operator)
+			return
+		}
+		c.emit(node.Line(), op)
+	case *ast.IfExpression:
+		c.compileExpression(node.Condition)
+		alternative := c.emitJump(node.Line(), OpJumpIfFalse)
+		c.compileBlock(node.Consequence, true)
+		end := c.emitJump(node.Line(), OpJump)
+		c.patchJump(alternative)
+		if node.Alternative != nil {
+			c.compileBlock(node.Alternative, true)
+		} else {
+			c.emit(node.Line(), OpNil)
+		}
+		c.patchJump(end)
+	case *ast.FunctionExpression:
+		if node.Name != nil {
+			c.compileFunction(node, node.Name.Value, namedExpression)
+		} else {
+			c.compileFunction(node, "", plainFunction)
+		}
+	case *ast.CallExpression:
+		c.compileExpression(*node.Function)
+		for _, arg := range node.Arguments {
+			c.compileExpression(*arg)
+		}
+		// 関数の中の末尾呼び出しはフレームを置き換えて実行する
+		op := OpCall
+		if node.Tail && c.current.tailCalls {
+			op = OpTailCall
+		}
+		c.emit(node.Line(), op, len(node.Arguments))
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			c.compileExpression(element)
+		}
+		c.emit(node.Line(), OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			c.compileExpression(pair.Key)
+			c.compileExpression(pair.Value)
+		}
+		c.emit(node.Line(), OpHash, len(node.Pairs))
+	case *ast.IndexExpression:
+		c.compileExpression(node.Left)
+		c.compileExpression(node.Index)
+		c.emit(node.Line(), OpIndex)
+	case *ast.AssignExpression:
+		c.compileAssignExpression(node)
+	case *ast.GetExpression:
+		c.compileExpression(node.Object)
+		c.emit(node.Line(), OpGetProperty, c.nameConstant(node.Name.Value))
+	case *ast.ThisExpression:
+		if c.class == noClass || !c.loadVariable("this", node.Line()) {
+			c.addError(node.Line(), "'this' used outside of a class")
+		}
+	case *ast.SuperExpression:
+		switch c.class {
+		case noClass:
+			c.addError(node.Line(), "'super' used outside of a class")
+			return
+		case plainClass:
+			c.addError(node.Line(), "'super' used in a class with no superclass")
+			return
+		}
+		if !c.loadVariable("this", node.Line()) || !c.loadVariable("super", node.Line()) {
+			c.addError(node.Line(), "'super' used outside of a method")
+			return
+		}
+		c.emit(node.Line(), OpGetSuper, c.nameConstant(node.Method.Value))
+	default:
+		c.addError(expression.Line(), "unsupported expression %T", expression)
+	}
+}
+
+var infixOperators = map[string]Opcode{
+	"+":  OpAdd,
+	"-":  OpSub,
+	"*":  OpMul,
+	"/":  OpDiv,
+	"%":  OpMod,
+	"==": OpEqual,
+	"!=": OpNotEqual,
+	"<":  OpLess,
+	"<=": OpLessEqual,
+	">":  OpGreater,
+	">=": OpGreaterEqual,
+}
+
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) {
+	c.compileExpression(node.Value)
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		switch kind, index := c.resolve(target.Value); kind {
+		case localVariable:
+			c.emit(target.Line(), OpSetLocal, index)
+		case upvalueVariable:
+			c.emit(target.Line(), OpSetUpvalue, index)
+		default:
+			c.emit(target.Line(), OpSetGlobal, c.nameConstant(target.Value))
+		}
+	case *ast.IndexExpression:
+		c.compileExpression(target.Left)
+		c.compileExpression(target.Index)
+		c.emit(target.Line(), OpSetIndex)
+	case *ast.GetExpression:
+		c.compileExpression(target.Object)
+		c.emit(target.Line(), OpSetProperty, c.nameConstant(target.Name.Value))
+	default:
+		c.addError(node.Line(), "invalid assignment target")
+	}
+}
+
+func (c *Compiler) compileIdentifier(name *ast.Identifier) {
+	if !c.loadVariable(name.Value, name.Line()) {
+		// グローバル変数と組み込み関数は実行時に名前で探す
+		c.emit(name.Line(), OpGetGlobal, c.nameConstant(name.Value))
+	}
+}
+
+// loadVariable はローカル変数か upvalue の name を読む命令を追加する
+// どちらでもなければ何もせずに false を返す
+func (c *Compiler) loadVariable(name string, line int) bool {
+	switch kind, index := c.resolve(name); kind {
+	case localVariable:
+		c.emit(line, OpGetLocal, index)
+	case upvalueVariable:
+		c.emit(line, OpGetUpvalue, index)
+	default:
+		return false
+	}
+	return true
+}
+
+type variableKind int
+
+const (
+	globalVariable variableKind = iota
+	localVariable
+	upvalueVariable
+)
+
+// resolve は評価器と同じく内側のスコープから name を探す
+func (c *Compiler) resolve(name string) (variableKind, int) {
+	if sym := c.current.lookup(name, false); sym != nil {
+		return localVariable, sym.slot
+	}
+	if index := c.resolveUpvalue(c.current, name); index >= 0 {
+		return upvalueVariable, index
+	}
+	return globalVariable, 0
+}
+
+// lookup は fs のローカル変数から name を探す
+// まだ宣言されていない変数は飛ばすが、内側の関数からの参照(crossesFunction)は
+// 呼び出される時点で宣言済みになっているとみなす
+func (fs *funcState) lookup(name string, crossesFunction bool) *symbol {
+	for i := len(fs.scopes) - 1; i >= 0; i-- {
+		s := fs.scopes[i]
+		if s.global {
+			break
+		}
+		if sym, ok := s.symbols[name]; ok && (sym.state == defined || crossesFunction) {
+			return sym
+		}
+	}
+	return nil
+}
+
+// resolveUpvalue は外側の関数の変数を fs の upvalue として捕まえ、その番号を返す
+func (c *Compiler) resolveUpvalue(fs *funcState, name string) int {
+	if fs.enclosing == nil {
+		return -1
+	}
+	if sym := fs.enclosing.lookup(name, true); sym != nil {
+		sym.captured = true
+		return fs.addUpvalue(true, sym.slot, name)
+	}
+	if index := c.resolveUpvalue(fs.enclosing, name); index >= 0 {
+		return fs.addUpvalue(false, index, name)
+	}
+	return -1
+}
+
+func (fs *funcState) addUpvalue(local bool, index int, name string) int {
+	for i, upvalue := range fs.fn.Upvalues {
+		if upvalue.Local == local && upvalue.Index == index {
+			return i
+		}
+	}
+	fs.fn.Upvalues = append(fs.fn.Upvalues, Upvalue{Local: local, Index: index, Name: name})
+	return len(fs.fn.Upvalues) - 1
+}
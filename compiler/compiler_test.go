@@ -0,0 +1,101 @@
+package compiler
+
+import (
+	"go-interpreter-practice/parser"
+	"go-interpreter-practice/scanner"
+	"testing"
+)
+
+func compile(t *testing.T, input string) (*Bytecode, []string) {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	c := NewCompiler()
+	bytecode, _ := c.Compile(program)
+	var messages []string
+	for _, err := range c.GetErrors() {
+		messages = append(messages, err.Error())
+	}
+	return bytecode, messages
+}
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpClass, []int{1, 1}, []byte{byte(OpClass), 0, 1, 1}},
+	}
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+		if string(instruction) != string(tt.expected) {
+			t.Errorf("Make(%d, %v) = %v, want %v", tt.op, tt.operands, instruction, tt.expected)
+		}
+	}
+}
+
+func TestCompileMain(t *testing.T) {
+	bytecode, errors := compile(t, "var x = 1\nprint x + 2")
+	if len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+	// トップレベルの変数は名前でグローバル環境に置く
+	expected := `0000 OpConstant 0
+0003 OpDefineGlobal 1
+0006 OpGetGlobal 1
+0009 OpConstant 2
+0012 OpAdd
+0013 OpPrint
+0014 OpNil
+0015 OpReturn
+`
+	if got := bytecode.Main.Instructions.String(); got != expected {
+		t.Errorf("wrong instructions.\ngot:\n%s\nwant:\n%s", got, expected)
+	}
+	if line := LineAt(bytecode.Main.Lines, 12); line != 2 {
+		t.Errorf("LineAt(12) = %d, want 2", line)
+	}
+}
+
+func TestCompileFunction(t *testing.T) {
+	bytecode, errors := compile(t, `func add(a, b = 1) { var c = a + b; return c }`)
+	if len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+	var fn *CompiledFunction
+	for _, constant := range bytecode.Constants {
+		if f, ok := constant.(*CompiledFunction); ok {
+			fn = f
+		}
+	}
+	if fn == nil {
+		t.Fatal("no compiled function in constants")
+	}
+	// slot 0 は関数自身、引数、ローカル変数の順に並ぶ
+	if fn.Name != "add" || fn.NumParams != 2 || fn.MinArity != 1 || fn.NumLocals != 4 {
+		t.Errorf("wrong function: name=%s params=%d min=%d locals=%d", fn.Name, fn.NumParams, fn.MinArity, fn.NumLocals)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"this", "line 1; 'this' used outside of a class"},
+		{"class A { f() { return super.f() } }", "line 1; 'super' used in a class with no superclass"},
+		{"class A < A {}", "line 1; a class can't inherit from itself"},
+	}
+	for _, tt := range tests {
+		_, errors := compile(t, tt.input)
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("%q: got %v, want %q", tt.input, errors, tt.expected)
+		}
+	}
+}
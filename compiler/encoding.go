@@ -0,0 +1,287 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"go-interpreter-practice/object"
+)
+
+// コンパイル済みファイル(.onuc)の形式
+//
+//	"ONUC"             マジックナンバー
+//	version  uint16    FormatVersion
+//	checksum uint32    本体の CRC-32 (IEEE)
+//	本体:
+//	  filename   string
+//	  constants  uint32 個の定数(1 バイトのタグと値)
+//	  main       function
+//
+// 整数はすべてビッグエンディアン、string は uint32 の長さとバイト列
+// function は名前、命令列、行番号表、ローカル変数の数などを順に並べたもの
+var magic = []byte("ONUC")
+
+// FormatVersion はコンパイル済みファイルの形式のバージョン
+// 命令や定数の表し方を変えたら上げる
+const FormatVersion = 1
+
+// 定数のタグ
+const (
+	tagInteger  byte = 'i'
+	tagFloat    byte = 'f'
+	tagString   byte = 's'
+	tagFunction byte = 'F'
+)
+
+// 関数のフラグ
+const (
+	flagHasRest byte = 1 << iota
+	flagInitializer
+)
+
+// Encode は bytecode をコンパイル済みファイルの形式で w に書き出す
+// filename はエラーメッセージとソースの更新確認に使うファイル名
+func Encode(w io.Writer, bytecode *Bytecode, filename string) error {
+	e := &encoder{}
+	e.string(filename)
+	e.uint32(len(bytecode.Constants))
+	for _, constant := range bytecode.Constants {
+		if err := e.constant(constant); err != nil {
+			return err
+		}
+	}
+	e.function(bytecode.Main)
+
+	header := make([]byte, len(magic)+6)
+	copy(header, magic)
+	binary.BigEndian.PutUint16(header[len(magic):], FormatVersion)
+	binary.BigEndian.PutUint32(header[len(magic)+2:], crc32.ChecksumIEEE(e.buf.Bytes()))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// Decode はコンパイル済みファイルを読み込み、bytecode とファイル名を返す
+// 形式やバージョンが違う場合、チェックサムが合わない場合、
+// 命令が範囲外の定数やローカル変数、ジャンプ先を指している場合はエラーを返す
+func Decode(data []byte) (*Bytecode, string, error) {
+	headerLen := len(magic) + 6
+	if len(data) < headerLen || !bytes.Equal(data[:len(magic)], magic) {
+		return nil, "", fmt.Errorf("not a compiled onu file")
+	}
+	if version := binary.BigEndian.Uint16(data[len(magic):]); version != FormatVersion {
+		return nil, "", fmt.Errorf("unsupported format version %d (want %d)", version, FormatVersion)
+	}
+	body := data[headerLen:]
+	if binary.BigEndian.Uint32(data[len(magic)+2:]) != crc32.ChecksumIEEE(body) {
+		return nil, "", fmt.Errorf("checksum mismatch")
+	}
+
+	d := &decoder{data: body}
+	filename := d.string()
+	constants := make([]object.Object, d.count())
+	for i := range constants {
+		constants[i] = d.constant()
+	}
+	main := d.function()
+	if d.err == nil && d.offset != len(d.data) {
+		d.err = fmt.Errorf("unexpected data after main function")
+	}
+	if d.err != nil {
+		return nil, "", d.err
+	}
+
+	// 定数プールはプログラム全体で一つなので、すべての関数で同じものを共有する
+	main.Constants = constants
+	for _, constant := range constants {
+		if fn, ok := constant.(*CompiledFunction); ok {
+			fn.Constants = constants
+		}
+	}
+	bytecode := &Bytecode{Main: main, Constants: constants}
+	if err := validate(bytecode); err != nil {
+		return nil, "", fmt.Errorf("invalid bytecode: %w", err)
+	}
+	return bytecode, filename, nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) uint32(n int) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) uint64(n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(len(s))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) constant(obj object.Object) error {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		e.buf.WriteByte(tagInteger)
+		e.uint64(uint64(obj.Value))
+	case *object.Float:
+		e.buf.WriteByte(tagFloat)
+		e.uint64(math.Float64bits(obj.Value))
+	case *object.String:
+		e.buf.WriteByte(tagString)
+		e.string(obj.Value)
+	case *CompiledFunction:
+		e.buf.WriteByte(tagFunction)
+		e.function(obj)
+	default:
+		return fmt.Errorf("cannot encode constant of type %s", obj.Type())
+	}
+	return nil
+}
+
+func (e *encoder) function(fn *CompiledFunction) {
+	e.string(fn.Name)
+	e.uint32(len(fn.Instructions))
+	e.buf.Write(fn.Instructions)
+	e.uint32(len(fn.Lines))
+	for _, line := range fn.Lines {
+		e.uint32(line.Offset)
+		e.uint32(line.Line)
+	}
+	e.uint32(fn.NumLocals)
+	e.uint32(fn.NumParams)
+	e.uint32(fn.MinArity)
+	var flags byte
+	if fn.HasRest {
+		flags |= flagHasRest
+	}
+	if fn.IsInitializer {
+		flags |= flagInitializer
+	}
+	e.buf.WriteByte(flags)
+	e.uint32(len(fn.Upvalues))
+	for _, upvalue := range fn.Upvalues {
+		if upvalue.Local {
+			e.buf.WriteByte(1)
+		} else {
+			e.buf.WriteByte(0)
+		}
+		e.uint32(upvalue.Index)
+		e.string(upvalue.Name)
+	}
+	e.string(fn.Source)
+}
+
+// decoder は最初のエラーを覚えておき、それ以降はゼロ値を返す
+// 呼び出し側は最後に一度だけ err を確認すればよい
+type decoder struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.data)-d.offset {
+		d.err = fmt.Errorf("unexpected end of data at offset %d", d.offset)
+		return nil
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b
+}
+
+func (d *decoder) byte() byte {
+	b := d.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) uint32() int {
+	b := d.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// count は要素の数を読む
+// 壊れたファイルで巨大なスライスを作らないよう、残りのバイト数を超える数はエラーにする
+func (d *decoder) count() int {
+	n := d.uint32()
+	if n > len(d.data)-d.offset {
+		if d.err == nil {
+			d.err = fmt.Errorf("invalid count %d at offset %d", n, d.offset)
+		}
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.uint32()))
+}
+
+func (d *decoder) constant() object.Object {
+	switch tag := d.byte(); tag {
+	case tagInteger:
+		return object.NewInteger(int(d.uint64()))
+	case tagFloat:
+		return object.NewFloat(math.Float64frombits(d.uint64()))
+	case tagString:
+		return object.NewString(d.string())
+	case tagFunction:
+		return d.function()
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown constant tag %q", tag)
+		}
+		return nil
+	}
+}
+
+func (d *decoder) function() *CompiledFunction {
+	fn := &CompiledFunction{Name: d.string()}
+	fn.Instructions = append(Instructions{}, d.bytes(d.uint32())...)
+	fn.Lines = make([]LineInfo, d.count())
+	for i := range fn.Lines {
+		fn.Lines[i] = LineInfo{Offset: d.uint32(), Line: d.uint32()}
+	}
+	fn.NumLocals = d.uint32()
+	fn.NumParams = d.uint32()
+	fn.MinArity = d.uint32()
+	flags := d.byte()
+	fn.HasRest = flags&flagHasRest != 0
+	fn.IsInitializer = flags&flagInitializer != 0
+	fn.Upvalues = make([]Upvalue, d.count())
+	for i := range fn.Upvalues {
+		fn.Upvalues[i] = Upvalue{Local: d.byte() == 1, Index: d.uint32(), Name: d.string()}
+	}
+	fn.Source = d.string()
+	return fn
+}
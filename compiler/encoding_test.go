@@ -0,0 +1,153 @@
+package compiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go-interpreter-practice/object"
+)
+
+func encode(t *testing.T, input string) []byte {
+	t.Helper()
+	bytecode, errors := compile(t, input)
+	if len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, bytecode, "dir/test.onu"); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	input := `var n = -9223372036854775807 - 1
+var f = 1.5
+class A < B {
+  init(x) { this.x = x }
+  get(y = 2, ...rest) { return func() { return this.x + y } }
+}
+class B {}
+print "done"`
+	original, _ := compile(t, input)
+	data := encode(t, input)
+	decoded, filename, err := Decode(data)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if filename != "dir/test.onu" {
+		t.Errorf("filename = %q, want %q", filename, "dir/test.onu")
+	}
+	if len(decoded.Constants) != len(original.Constants) {
+		t.Fatalf("got %d constants, want %d", len(decoded.Constants), len(original.Constants))
+	}
+	for i, constant := range original.Constants {
+		got := decoded.Constants[i]
+		if got.Type() != constant.Type() || got.String() != constant.String() {
+			t.Errorf("constant %d = %s %q, want %s %q", i, got.Type(), got, constant.Type(), constant)
+		}
+		fn, ok := constant.(*CompiledFunction)
+		if !ok {
+			continue
+		}
+		decodedFn := got.(*CompiledFunction)
+		if decodedFn.Instructions.String() != fn.Instructions.String() {
+			t.Errorf("constant %d: wrong instructions.\ngot:\n%s\nwant:\n%s", i, decodedFn.Instructions, fn.Instructions)
+		}
+		if decodedFn.Name != fn.Name || decodedFn.NumLocals != fn.NumLocals || decodedFn.NumParams != fn.NumParams ||
+			decodedFn.MinArity != fn.MinArity || decodedFn.HasRest != fn.HasRest || decodedFn.IsInitializer != fn.IsInitializer ||
+			len(decodedFn.Upvalues) != len(fn.Upvalues) || len(decodedFn.Lines) != len(fn.Lines) {
+			t.Errorf("constant %d: got %+v, want %+v", i, decodedFn, fn)
+		}
+		// 定数プールは main と共有する
+		if &decodedFn.Constants[0] != &decoded.Constants[0] {
+			t.Errorf("constant %d does not share the constant pool", i)
+		}
+	}
+	if decoded.Main.Instructions.String() != original.Main.Instructions.String() {
+		t.Errorf("wrong main instructions.\ngot:\n%s\nwant:\n%s", decoded.Main.Instructions, original.Main.Instructions)
+	}
+	if &decoded.Main.Constants[0] != &decoded.Constants[0] {
+		t.Errorf("main does not share the constant pool")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	data := encode(t, `print 1`)
+
+	version := append([]byte{}, data...)
+	version[5] = FormatVersion + 1
+
+	checksum := append([]byte{}, data...)
+	checksum[len(checksum)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"empty", []byte{}, "not a compiled onu file"},
+		{"magic", []byte("print 1 + 2 + 3"), "not a compiled onu file"},
+		{"version", version, "unsupported format version 2 (want 1)"},
+		{"checksum", checksum, "checksum mismatch"},
+		{"truncated", data[:len(data)-3], "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		_, _, err := Decode(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.expected)
+		}
+	}
+}
+
+// 手で組み立てた不正な命令列は、チェックサムが合っていても読み込む時点でエラーにする
+func TestDecodeInvalidBytecode(t *testing.T) {
+	instructions := func(parts ...[]byte) Instructions {
+		var ins Instructions
+		for _, part := range parts {
+			ins = append(ins, part...)
+		}
+		return ins
+	}
+	ret := Make(OpReturn)
+	closure := &CompiledFunction{Name: "inner", NumLocals: 1, Instructions: ret, Upvalues: []Upvalue{{Local: true, Index: 3, Name: "x"}}}
+
+	tests := []struct {
+		name      string
+		main      *CompiledFunction
+		constants []object.Object
+		expected  string
+	}{
+		{"constant index", &CompiledFunction{NumLocals: 1, Instructions: instructions(Make(OpConstant, 5), ret)}, nil,
+			"function <anonymous>: offset 0: OpConstant: constant 5 out of range (0 constants)"},
+		{"name constant", &CompiledFunction{NumLocals: 1, Instructions: instructions(Make(OpGetGlobal, 0), ret)}, []object.Object{object.NewInteger(1)},
+			"offset 0: OpGetGlobal: constant 0 is INTEGER, not a name"},
+		{"jump target", &CompiledFunction{NumLocals: 1, Instructions: instructions(Make(OpJump, 2), ret)}, nil,
+			"offset 0: OpJump: invalid jump target 2"},
+		{"local slot", &CompiledFunction{NumLocals: 1, Instructions: instructions(Make(OpGetLocal, 1), ret)}, nil,
+			"offset 0: OpGetLocal: local slot 1 out of range (1 locals)"},
+		{"upvalue", &CompiledFunction{NumLocals: 1, Instructions: instructions(Make(OpGetUpvalue, 0), ret)}, nil,
+			"offset 0: OpGetUpvalue: upvalue 0 out of range (0 upvalues)"},
+		{"closure upvalue", &CompiledFunction{NumLocals: 2, Instructions: instructions(Make(OpClosure, 0), ret)}, []object.Object{closure},
+			"offset 0: OpClosure: function inner captures invalid variable x"},
+		{"unknown opcode", &CompiledFunction{NumLocals: 1, Instructions: Instructions{255}}, nil,
+			"offset 0: opcode 255 undefined"},
+		{"truncated operand", &CompiledFunction{NumLocals: 1, Instructions: instructions(ret, []byte{byte(OpConstant), 0})}, nil,
+			"offset 1: truncated OpConstant"},
+		{"missing return", &CompiledFunction{NumLocals: 1, Instructions: Make(OpNil)}, nil,
+			"instructions do not end with OpReturn"},
+		{"frame layout", &CompiledFunction{NumLocals: 1, NumParams: 1, Instructions: ret}, nil,
+			"invalid frame layout (1 locals, 1 params, min arity 0)"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Encode(&buf, &Bytecode{Main: tt.main, Constants: tt.constants}, "bad.onu"); err != nil {
+			t.Fatalf("%s: encode error: %v", tt.name, err)
+		}
+		_, _, err := Decode(buf.Bytes())
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.expected)
+		}
+	}
+}
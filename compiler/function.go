@@ -0,0 +1,62 @@
+package compiler
+
+import "go-interpreter-practice/object"
+
+const COMPILED_FUNCTION object.ObjectType = "COMPILED_FUNCTION"
+
+// CompiledFunction はコンパイルした関数本体
+// 定数プールに入り、vm が OpClosure でクロージャにする
+//
+// 呼び出されたときのフレームは slot 0 に関数自身(メソッドなら this)を置き、
+// slot 1 から引数、可変長引数、本体のローカル変数が続く
+type CompiledFunction struct {
+	Name          string // 無名関数の場合は空文字
+	Instructions  Instructions
+	Lines         []LineInfo
+	NumLocals     int // slot 0 を含むローカル変数の数
+	NumParams     int
+	MinArity      int  // 省略できない引数の数
+	HasRest       bool // 可変長引数を持つかどうか
+	IsInitializer bool
+	Upvalues      []Upvalue // クロージャを作るときに捕まえる変数
+	Source        string    // String で表示する内容(評価器の Function と同じ)
+
+	// Constants はこの関数を含むプログラム全体の定数プール
+	// REPL で前に実行したプログラムの関数を呼んでも、その関数の定数を使えるように関数ごとに持つ
+	Constants []object.Object
+}
+
+// Upvalue はクロージャが捕まえる外側の変数
+// Local なら外側の関数の Index 番目のローカル変数、そうでなければ外側の関数の Index 番目の upvalue
+type Upvalue struct {
+	Local bool
+	Index int
+	Name  string
+}
+
+func (f *CompiledFunction) Type() object.ObjectType {
+	return COMPILED_FUNCTION
+}
+
+func (f *CompiledFunction) String() string {
+	return f.Source
+}
+
+func (f *CompiledFunction) IsTruthy() bool {
+	return true
+}
+
+// DisplayName はエラーメッセージ用の関数名を返す
+func (f *CompiledFunction) DisplayName() string {
+	if f.Name == "" {
+		return "<anonymous>"
+	}
+	return f.Name
+}
+
+// Bytecode はコンパイルしたプログラム
+// Main はトップレベルの文をまとめた関数で、引数を取らない
+type Bytecode struct {
+	Main      *CompiledFunction
+	Constants []object.Object
+}
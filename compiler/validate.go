@@ -0,0 +1,141 @@
+package compiler
+
+import (
+	"fmt"
+
+	"go-interpreter-practice/object"
+)
+
+// validate は読み込んだプログラムを vm が範囲外を読まずに実行できるかを確かめる
+// 命令とオペランドが命令列に収まり、定数の番号、ジャンプ先、ローカル変数と upvalue の番号が
+// それぞれの範囲にあり、定数の型が命令の期待どおりであることを確かめる
+// スタックの深さまでは確かめない
+func validate(bytecode *Bytecode) error {
+	functions := []*CompiledFunction{bytecode.Main}
+	for _, constant := range bytecode.Constants {
+		if fn, ok := constant.(*CompiledFunction); ok {
+			functions = append(functions, fn)
+		}
+	}
+	for _, fn := range functions {
+		if err := validateFunction(fn, bytecode.Constants); err != nil {
+			return fmt.Errorf("function %s: %w", fn.DisplayName(), err)
+		}
+	}
+	return nil
+}
+
+func validateFunction(fn *CompiledFunction, constants []object.Object) error {
+	reserved := 1 + fn.NumParams // slot 0 と引数
+	if fn.HasRest {
+		reserved++
+	}
+	if fn.NumLocals < reserved || fn.MinArity > fn.NumParams {
+		return fmt.Errorf("invalid frame layout (%d locals, %d params, min arity %d)", fn.NumLocals, fn.NumParams, fn.MinArity)
+	}
+
+	// ジャンプ先が命令の途中を指していないか確かめるため、先に命令の先頭を集める
+	ins := fn.Instructions
+	starts := make(map[int]bool)
+	last := Opcode(0)
+	for offset := 0; offset < len(ins); {
+		def, err := Lookup(ins[offset])
+		if err != nil {
+			return fmt.Errorf("offset %d: %w", offset, err)
+		}
+		width := 0
+		for _, w := range def.OperandWidths {
+			width += w
+		}
+		if offset+1+width > len(ins) {
+			return fmt.Errorf("offset %d: truncated %s", offset, def.Name)
+		}
+		starts[offset] = true
+		last = Opcode(ins[offset])
+		offset += 1 + width
+	}
+	// 命令列の終わりを越えて実行しないよう、最後は必ず OpReturn
+	if len(ins) == 0 || last != OpReturn {
+		return fmt.Errorf("instructions do not end with OpReturn")
+	}
+
+	for offset := 0; offset < len(ins); {
+		op := Opcode(ins[offset])
+		def := definitions[op]
+		operands, read := ReadOperands(def, ins[offset+1:])
+		if err := validateOperands(fn, op, operands, constants, starts); err != nil {
+			return fmt.Errorf("offset %d: %s: %w", offset, def.Name, err)
+		}
+		offset += 1 + read
+	}
+	return nil
+}
+
+func validateOperands(fn *CompiledFunction, op Opcode, operands []int, constants []object.Object, starts map[int]bool) error {
+	constant := func(i int) (object.Object, error) {
+		if i >= len(constants) {
+			return nil, fmt.Errorf("constant %d out of range (%d constants)", i, len(constants))
+		}
+		return constants[i], nil
+	}
+	name := func(i int) error {
+		c, err := constant(i)
+		if err != nil {
+			return err
+		}
+		if _, ok := c.(*object.String); !ok {
+			return fmt.Errorf("constant %d is %s, not a name", i, c.Type())
+		}
+		return nil
+	}
+	slot := func(i int) error {
+		if i >= fn.NumLocals {
+			return fmt.Errorf("local slot %d out of range (%d locals)", i, fn.NumLocals)
+		}
+		return nil
+	}
+	jump := func(target int) error {
+		if !starts[target] {
+			return fmt.Errorf("invalid jump target %d", target)
+		}
+		return nil
+	}
+
+	switch op {
+	case OpConstant:
+		_, err := constant(operands[0])
+		return err
+	case OpGetGlobal, OpSetGlobal, OpDefineGlobal, OpGetProperty, OpSetProperty, OpGetSuper, OpMethod, OpClass:
+		return name(operands[0])
+	case OpGetLocal, OpSetLocal, OpCloseUpvalues:
+		return slot(operands[0])
+	case OpGetUpvalue, OpSetUpvalue:
+		if operands[0] >= len(fn.Upvalues) {
+			return fmt.Errorf("upvalue %d out of range (%d upvalues)", operands[0], len(fn.Upvalues))
+		}
+	case OpJump, OpJumpIfFalse:
+		return jump(operands[0])
+	case OpJumpIfProvided, OpIterNext:
+		if err := slot(operands[0]); err != nil {
+			return err
+		}
+		return jump(operands[1])
+	case OpClosure:
+		c, err := constant(operands[0])
+		if err != nil {
+			return err
+		}
+		closure, ok := c.(*CompiledFunction)
+		if !ok {
+			return fmt.Errorf("constant %d is %s, not a function", operands[0], c.Type())
+		}
+		// クロージャが捕まえる変数はこの関数のローカル変数か upvalue
+		for _, upvalue := range closure.Upvalues {
+			if upvalue.Local && upvalue.Index >= fn.NumLocals ||
+				!upvalue.Local && upvalue.Index >= len(fn.Upvalues) {
+				return fmt.Errorf("function %s captures invalid variable %s", closure.DisplayName(), upvalue.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+// Package dap は Debug Adapter Protocol (DAP) で debugger を操作するサーバー
+//
+// VS Code や Neovim などのエディタから、スクリプトの起動、ブレークポイント、ステップ実行、
+// 呼び出しスタックと変数の表示、式の評価ができる
+// スクリプトは一つのスレッドとして見せる
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go-interpreter-practice/debugger"
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/onu"
+)
+
+// threadID はスクリプトを実行するスレッドの ID
+const threadID = 1
+
+// errNotStopped は一時停止していないときに、止まっている間だけ使える要求を受けたときのエラー
+var errNotStopped = errors.New("the program is not paused")
+
+// Server は一つのクライアントと DAP でやりとりする
+type Server struct {
+	in             *bufio.Reader
+	out            io.Writer
+	newInterpreter func() *onu.Interpreter
+
+	writeMu sync.Mutex
+	seq     int
+
+	mu          sync.Mutex
+	debugger    *debugger.Debugger
+	program     string // 起動したスクリプトのパス
+	src         string
+	breakpoints []int // launch より前に設定されたブレークポイント
+	configured  bool  // configurationDone を受け取ったか
+	started     bool
+	stopped     bool
+	references  map[int][]debugger.Variable // 止まっている間だけ有効な variablesReference
+	resume      chan struct{}
+	done        chan struct{} // スクリプトの実行が終わると閉じる
+}
+
+// NewServer は in から要求を読み、out に応答とイベントを書くサーバーを作る
+// スクリプトは newInterpreter で作った Interpreter で実行する(組み込み関数の登録などに使う)
+func NewServer(in io.Reader, out io.Writer, newInterpreter func() *onu.Interpreter) *Server {
+	return &Server{
+		in:             bufio.NewReader(in),
+		out:            out,
+		newInterpreter: newInterpreter,
+		resume:         make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Listen は addr (host:port) で TCP の接続を待つ
+// 接続したクライアントは launch でどのファイルでも読んで実行できるので、
+// :4711 のようにホストを省いたときは全てのインターフェースではなくループバックだけで待つ
+func Listen(addr string) (net.Listener, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.Listen("tcp", net.JoinHostPort(host, port))
+}
+
+// IsLoopback は addr がループバックのアドレスかどうかを返す
+func IsLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// Serve は listener で接続を受け付け、接続ごとにサーバーを動かす
+// クライアントを認証しないので、信頼できるクライアントだけが接続できるアドレスで使うこと
+func Serve(listener net.Listener, newInterpreter func() *onu.Interpreter) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			NewServer(conn, conn, newInterpreter).Serve()
+		}()
+	}
+}
+
+// Serve は disconnect を受け取るか入力が終わるまで要求を処理する
+// 実行中のスクリプトは止めてから戻る
+func (s *Server) Serve() error {
+	defer s.shutdown()
+	for {
+		data, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid message: %v", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		body, after, err := s.handle(&req)
+		s.respond(&req, body, err)
+		if after != nil {
+			after()
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// handle は要求を処理し、応答の本体と、応答を送った後で行う処理を返す
+func (s *Server) handle(req *request) (interface{}, func(), error) {
+	switch req.Command {
+	case "initialize":
+		capabilities := map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}
+		return capabilities, func() { s.sendEvent("initialized", nil) }, nil
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, s.launch(args)
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, nil, err
+		}
+		return s.setBreakpoints(args), nil, nil
+	case "configurationDone":
+		s.mu.Lock()
+		s.configured = true
+		s.mu.Unlock()
+		return nil, s.start, nil
+	case "threads":
+		return map[string]interface{}{"threads": []thread{{ID: threadID, Name: "main"}}}, nil, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args frameArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, nil, err
+		}
+		return s.scopes(args)
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, nil, err
+		}
+		return s.variables(args)
+	case "evaluate":
+		var args evaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, nil, err
+		}
+		return s.evaluate(args)
+	case "continue":
+		return s.step(func(d *debugger.Debugger) { d.Continue() }, map[string]bool{"allThreadsContinued": true})
+	case "next":
+		return s.step(func(d *debugger.Debugger) { d.StepOver() }, nil)
+	case "stepIn":
+		return s.step(func(d *debugger.Debugger) { d.StepIn() }, nil)
+	case "stepOut":
+		return s.step(func(d *debugger.Debugger) { d.StepOut() }, nil)
+	case "pause":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.debugger != nil && !s.stopped {
+			s.debugger.Pause()
+		}
+		return nil, nil, nil
+	case "terminate", "disconnect":
+		return nil, s.shutdown, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported request %q", req.Command)
+}
+
+// launch はスクリプトを読み込んでデバッガを用意する
+// 実行は configurationDone を受け取ってから始める
+func (s *Server) launch(args launchArguments) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.debugger != nil {
+		return errors.New("the program is already launched")
+	}
+	src, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	interpreter := s.newInterpreter()
+	// 標準入出力は DAP のやりとりに使っているかもしれないので、スクリプトの入出力は output イベントにする
+	interpreter.SetStdin(strings.NewReader(""))
+	interpreter.SetStdout(&output{server: s, category: "stdout"})
+	interpreter.SetStderr(&output{server: s, category: "stderr"})
+
+	s.program, s.src = args.Program, string(src)
+	s.debugger = debugger.New(interpreter, s, args.StopOnEntry && !args.NoDebug)
+	if !args.NoDebug {
+		for _, line := range s.breakpoints {
+			s.debugger.SetBreakpoint(line)
+		}
+	}
+	return nil
+}
+
+// start は launch と configurationDone の両方を受け取ったらスクリプトの実行を始める
+func (s *Server) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.debugger == nil || !s.configured || s.started {
+		return
+	}
+	s.started = true
+	d, program, src := s.debugger, s.program, s.src
+	go func() {
+		defer close(s.done)
+		result, err := d.Run(context.Background(), program, src)
+		exitCode := 0
+		switch {
+		case d.Terminated():
+		case err != nil:
+			s.sendEvent("output", map[string]string{"category": "stderr", "output": err.Error() + "\n"})
+			exitCode = 1
+		case result != nil && result != object.NilObject:
+			s.sendEvent("output", map[string]string{"category": "console", "output": result.String() + "\n"})
+		}
+		s.sendEvent("exited", map[string]int{"exitCode": exitCode})
+		s.sendEvent("terminated", nil)
+	}()
+}
+
+// shutdown は実行中のスクリプトを止めて、終わるのを待つ
+func (s *Server) shutdown() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	// Stopped と同じロックの中で止めるので、これから止まろうとしているスクリプトを待ち続けることはない
+	s.debugger.Terminate()
+	stopped := s.stopped
+	s.stopped = false
+	s.mu.Unlock()
+	if stopped {
+		s.resume <- struct{}{}
+	}
+	<-s.done
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) interface{} {
+	lines := args.Lines
+	if args.Breakpoints != nil {
+		lines = lines[:0]
+		for _, b := range args.Breakpoints {
+			lines = append(lines, b.Line)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	breakpoints := make([]breakpoint, len(lines))
+	if s.program != "" && !sameFile(args.Source.Path, s.program) {
+		// 一つのファイルしか実行しないので、他のファイルのブレークポイントは使えない
+		for i, line := range lines {
+			breakpoints[i] = breakpoint{Line: line, Message: "not the launched program"}
+		}
+		return map[string]interface{}{"breakpoints": breakpoints}
+	}
+	s.breakpoints = append([]int(nil), lines...)
+	if s.debugger != nil {
+		s.debugger.ClearBreakpoints()
+	}
+	for i, line := range lines {
+		if s.debugger != nil {
+			s.debugger.SetBreakpoint(line)
+		}
+		breakpoints[i] = breakpoint{Verified: true, Line: line}
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}
+}
+
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// pausedDebugger は一時停止しているときだけデバッガを返す
+// このときスクリプトを実行するゴルーチンは Stopped の中で待っているので、デバッガを調べてよい
+func (s *Server) pausedDebugger() (*debugger.Debugger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		return nil, errNotStopped
+	}
+	return s.debugger, nil
+}
+
+func (s *Server) stackTrace() (interface{}, func(), error) {
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, nil, err
+	}
+	src := source{Name: filepath.Base(s.program), Path: s.program}
+	stack := d.Stack()
+	frames := make([]stackFrame, len(stack))
+	for i, f := range stack {
+		frames[i] = stackFrame{ID: i + 1, Name: f.Function, Source: src, Line: f.Line, Column: 1}
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil, nil
+}
+
+// frame は stackTrace で返した ID のフレームを返す
+// ID が 0 なら一番内側のフレームを使う
+func (s *Server) frame(d *debugger.Debugger, id int) (*debugger.Frame, error) {
+	if id == 0 {
+		id = 1
+	}
+	f, ok := d.Frame(id - 1)
+	if !ok {
+		return nil, fmt.Errorf("unknown frame %d", id)
+	}
+	return f, nil
+}
+
+func (s *Server) scopes(args frameArguments) (interface{}, func(), error) {
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := s.frame(d, args.FrameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var scopes []scope
+	for _, sc := range d.Scopes(f) {
+		scopes = append(scopes, scope{Name: sc.Name, VariablesReference: s.reference(sc.Variables)})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil, nil
+}
+
+func (s *Server) variables(args variablesArguments) (interface{}, func(), error) {
+	if _, err := s.pausedDebugger(); err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	vars, ok := s.references[args.VariablesReference]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown variablesReference %d", args.VariablesReference)
+	}
+	variables := make([]variable, len(vars))
+	for i, v := range vars {
+		variables[i] = s.variable(v.Name, v.Value)
+	}
+	return map[string]interface{}{"variables": variables}, nil, nil
+}
+
+// variable は値を表示用に変換する
+// 配列、ハッシュ、インスタンスは中を展開できるように variablesReference を付ける
+func (s *Server) variable(name string, value object.Object) variable {
+	v := variable{Name: name, Value: debugger.Describe(value)}
+	if value != nil {
+		v.Type = string(value.Type())
+	}
+	if children := debugger.Children(value); len(children) > 0 {
+		v.VariablesReference = s.reference(children)
+	}
+	return v
+}
+
+// reference は vars を後から variables で取り出せるように番号を付ける
+func (s *Server) reference(vars []debugger.Variable) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.references[len(s.references)+1] = vars
+	return len(s.references)
+}
+
+func (s *Server) evaluate(args evaluateArguments) (interface{}, func(), error) {
+	d, err := s.pausedDebugger()
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := s.frame(d, args.FrameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := d.Evaluate(f, args.Expression)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := s.variable(args.Expression, result)
+	return map[string]interface{}{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil, nil
+}
+
+// step は一時停止しているスクリプトの再開のしかたを設定し、応答を送ってから再開する
+func (s *Server) step(mode func(d *debugger.Debugger), body interface{}) (interface{}, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		return nil, nil, errNotStopped
+	}
+	mode(s.debugger)
+	s.stopped = false
+	return body, func() { s.resume <- struct{}{} }, nil
+}
+
+// Stopped は debugger.Frontend の実装
+// stopped イベントを送り、再開の要求を受け取るまで待つ
+func (s *Server) Stopped(d *debugger.Debugger, reason debugger.StopReason) {
+	s.mu.Lock()
+	if d.Terminated() {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.references = make(map[int][]debugger.Variable)
+	s.mu.Unlock()
+	s.sendEvent("stopped", map[string]interface{}{
+		"reason":            string(reason),
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+	<-s.resume
+}
+
+func (s *Server) respond(req *request, body interface{}, err error) {
+	res := &response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+	if err != nil {
+		res.Message = err.Error()
+	}
+	s.send(func(seq int) interface{} { res.Seq = seq; return res })
+}
+
+func (s *Server) sendEvent(name string, body interface{}) {
+	s.send(func(seq int) interface{} { return &event{Seq: seq, Type: "event", Event: name, Body: body} })
+}
+
+// send は番号を付けたメッセージを書く
+// スクリプトを実行するゴルーチンからもイベントを送るので、書き込みは一つずつ行う
+func (s *Server) send(message func(seq int) interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.seq++
+	writeMessage(s.out, message(s.seq))
+}
+
+// output はスクリプトの出力を output イベントにする
+type output struct {
+	server   *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.server.sendEvent("output", map[string]string{"category": o.category, "output": string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,340 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-interpreter-practice/onu"
+)
+
+// message はクライアントが受け取る応答とイベント
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client は要求を順に送り、決まった応答とイベントが来ることを確かめる DAP クライアント
+type client struct {
+	t      *testing.T
+	in     *bufio.Reader
+	out    io.Writer
+	seq    int
+	events []*message // 応答を待つ間に届いたイベント
+}
+
+func newClient(t *testing.T, in io.Reader, out io.Writer) *client {
+	return &client{t: t, in: bufio.NewReader(in), out: out}
+}
+
+func (c *client) read() *message {
+	c.t.Helper()
+	data, err := readMessage(c.in)
+	if err != nil {
+		c.t.Fatalf("read error: %v", err)
+	}
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		c.t.Fatalf("invalid message %s: %v", data, err)
+	}
+	return &m
+}
+
+// request は要求を送り、応答の本体を body に読み込む
+func (c *client) request(command string, args interface{}, body interface{}) *message {
+	c.t.Helper()
+	c.seq++
+	arguments, _ := json.Marshal(args)
+	if err := writeMessage(c.out, &request{Seq: c.seq, Type: "request", Command: command, Arguments: arguments}); err != nil {
+		c.t.Fatalf("write error: %v", err)
+	}
+	for {
+		m := c.read()
+		if m.Type == "event" {
+			c.events = append(c.events, m)
+			continue
+		}
+		if m.RequestSeq != c.seq || m.Command != command {
+			c.t.Fatalf("got response to %s (%d), want %s (%d)", m.Command, m.RequestSeq, command, c.seq)
+		}
+		if body != nil && m.Success {
+			if err := json.Unmarshal(m.Body, body); err != nil {
+				c.t.Fatalf("%s: invalid body %s: %v", command, m.Body, err)
+			}
+		}
+		return m
+	}
+}
+
+// ok は要求が成功することを確かめる
+func (c *client) ok(command string, args interface{}, body interface{}) {
+	c.t.Helper()
+	if m := c.request(command, args, body); !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	}
+}
+
+// event は name のイベントが来るまで待ち、本体を body に読み込む
+// 途中の output イベントの出力は返り値にまとめる
+func (c *client) event(name string, body interface{}) string {
+	c.t.Helper()
+	var output strings.Builder
+	for {
+		var m *message
+		if len(c.events) > 0 {
+			m, c.events = c.events[0], c.events[1:]
+		} else {
+			m = c.read()
+		}
+		if m.Type != "event" {
+			c.t.Fatalf("got response to %s while waiting for %s", m.Command, name)
+		}
+		if m.Event == "output" && name != "output" {
+			var o struct{ Output string }
+			json.Unmarshal(m.Body, &o)
+			output.WriteString(o.Output)
+			continue
+		}
+		if m.Event != name {
+			c.t.Fatalf("got event %s, want %s", m.Event, name)
+		}
+		if body != nil {
+			json.Unmarshal(m.Body, body)
+		}
+		return output.String()
+	}
+}
+
+type stopped struct {
+	Reason   string `json:"reason"`
+	ThreadID int    `json:"threadId"`
+}
+
+// expectStop は stopped イベントを待ち、一番内側のフレームを確かめる
+func (c *client) expectStop(reason, function string, line int) {
+	c.t.Helper()
+	var s stopped
+	c.event("stopped", &s)
+	if s.Reason != reason || s.ThreadID != threadID {
+		c.t.Fatalf("stopped with %+v, want reason %s", s, reason)
+	}
+	frames := c.stackTrace()
+	if frames[0].Name != function || frames[0].Line != line {
+		c.t.Fatalf("stopped in %s at line %d, want %s at line %d", frames[0].Name, frames[0].Line, function, line)
+	}
+}
+
+func (c *client) stackTrace() []stackFrame {
+	c.t.Helper()
+	var body struct{ StackFrames []stackFrame }
+	c.ok("stackTrace", map[string]int{"threadId": threadID}, &body)
+	return body.StackFrames
+}
+
+// variables はスコープや値の中身を "名前=値" の並びにする
+func (c *client) variables(reference int) (string, map[string]variable) {
+	c.t.Helper()
+	var body struct{ Variables []variable }
+	c.ok("variables", map[string]int{"variablesReference": reference}, &body)
+	var list []string
+	byName := make(map[string]variable)
+	for _, v := range body.Variables {
+		list = append(list, v.Name+"="+v.Value)
+		byName[v.Name] = v
+	}
+	return strings.Join(list, " "), byName
+}
+
+func (c *client) evaluate(expression string, frameID int) string {
+	c.t.Helper()
+	var body struct{ Result string }
+	c.ok("evaluate", map[string]interface{}{"expression": expression, "frameId": frameID, "context": "repl"}, &body)
+	return body.Result
+}
+
+const program = `func add(a, b) {
+  var sum = a + b
+  return sum
+}
+var items = [1, "two", {"k": 3}]
+print add(1, 2)
+var total = add(3, 4)
+print total`
+
+func writeProgram(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.onu")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServerOverTCP(t *testing.T) {
+	path := writeProgram(t, program)
+	// ホストを省いたのでループバックだけで待つ
+	listener, err := Listen(":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if !IsLoopback(listener.Addr()) {
+		t.Errorf("listening on %s, want a loopback address", listener.Addr())
+	}
+	go Serve(listener, onu.New)
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	c := newClient(t, conn, conn)
+
+	var capabilities map[string]bool
+	c.ok("initialize", map[string]string{"adapterID": "onu"}, &capabilities)
+	if !capabilities["supportsConfigurationDoneRequest"] {
+		t.Errorf("got capabilities %v", capabilities)
+	}
+	c.event("initialized", nil)
+	c.ok("launch", map[string]interface{}{"program": path}, nil)
+
+	var breakpoints struct{ Breakpoints []breakpoint }
+	c.ok("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": []map[string]int{{"line": 2}}}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 1 || !breakpoints.Breakpoints[0].Verified {
+		t.Errorf("got breakpoints %+v", breakpoints.Breakpoints)
+	}
+	c.ok("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": "other.onu"}, "breakpoints": []map[string]int{{"line": 1}}}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 1 || breakpoints.Breakpoints[0].Verified {
+		t.Errorf("got breakpoints %+v for another file", breakpoints.Breakpoints)
+	}
+	c.ok("configurationDone", nil, nil)
+	c.expectStop("breakpoint", "add", 2)
+
+	var threads struct{ Threads []thread }
+	c.ok("threads", nil, &threads)
+	if len(threads.Threads) != 1 || threads.Threads[0].ID != threadID {
+		t.Errorf("got threads %+v", threads.Threads)
+	}
+	frames := c.stackTrace()
+	if len(frames) != 2 || frames[1].Name != "<main>" || frames[1].Line != 6 || frames[1].Source.Path != path {
+		t.Errorf("got stack %+v", frames)
+	}
+
+	// スコープは環境をたどってローカルとグローバルに分ける
+	var scopes struct{ Scopes []scope }
+	c.ok("scopes", map[string]int{"frameId": frames[0].ID}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("got scopes %+v", scopes.Scopes)
+	}
+	if locals, _ := c.variables(scopes.Scopes[0].VariablesReference); locals != "a=1 b=2" {
+		t.Errorf("got locals %q", locals)
+	}
+	globals, byName := c.variables(scopes.Scopes[1].VariablesReference)
+	if globals != `add=func add items=[1, "two", {"k": 3}]` {
+		t.Errorf("got globals %q", globals)
+	}
+	if elements, _ := c.variables(byName["items"].VariablesReference); elements != `[0]=1 [1]="two" [2]={"k": 3}` {
+		t.Errorf("got elements %q", elements)
+	}
+
+	if got := c.evaluate("a * 10 + b", frames[0].ID); got != "12" {
+		t.Errorf("evaluate got %q", got)
+	}
+	if m := c.request("evaluate", map[string]interface{}{"expression": "missing", "frameId": frames[0].ID}, nil); m.Success || !strings.Contains(m.Message, "missing") {
+		t.Errorf("evaluate of an undefined name: %+v", m)
+	}
+
+	c.ok("next", map[string]int{"threadId": threadID}, nil)
+	c.expectStop("step", "add", 3)
+	if got := c.evaluate("sum", 0); got != "3" {
+		t.Errorf("evaluate got %q", got)
+	}
+	c.ok("stepOut", map[string]int{"threadId": threadID}, nil)
+	var s stopped
+	if output := c.event("stopped", &s); output != "3\n" || s.Reason != "step" {
+		t.Errorf("got output %q and %+v", output, s)
+	}
+	c.ok("stepIn", map[string]int{"threadId": threadID}, nil)
+	c.expectStop("step", "add", 2)
+
+	c.ok("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": []map[string]int{}}, nil)
+	c.ok("continue", map[string]int{"threadId": threadID}, nil)
+	var exited struct{ ExitCode int }
+	if output := c.event("exited", &exited); output != "7\n" || exited.ExitCode != 0 {
+		t.Errorf("got output %q and exit code %d", output, exited.ExitCode)
+	}
+	c.event("terminated", nil)
+	if m := c.request("stackTrace", map[string]int{"threadId": threadID}, nil); m.Success {
+		t.Errorf("stackTrace succeeded after the program exited")
+	}
+	c.ok("disconnect", nil, nil)
+}
+
+func TestServerPauseAndDisconnect(t *testing.T) {
+	path := writeProgram(t, "func loop(n) {\n  return loop(n + 1)\n}\nloop(0)")
+	// 標準入出力と同じく OS のパイプでつなぐ
+	clientIn, serverOut, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverIn, clientOut, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientIn.Close()
+	defer clientOut.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	clientIn.SetDeadline(deadline)
+	clientOut.SetDeadline(deadline)
+	served := make(chan error)
+	go func() {
+		served <- NewServer(serverIn, serverOut, onu.New).Serve()
+		serverOut.Close()
+	}()
+	c := newClient(t, clientIn, clientOut)
+
+	c.ok("initialize", nil, nil)
+	c.ok("launch", map[string]interface{}{"program": path, "stopOnEntry": true}, nil)
+	c.ok("configurationDone", nil, nil)
+	c.event("initialized", nil)
+	c.expectStop("entry", "<main>", 1)
+	if m := c.request("pause", map[string]int{"threadId": threadID}, nil); !m.Success {
+		t.Errorf("pause while stopped failed: %s", m.Message)
+	}
+
+	c.ok("continue", map[string]int{"threadId": threadID}, nil)
+	if m := c.request("stackTrace", map[string]int{"threadId": threadID}, nil); m.Success {
+		t.Errorf("stackTrace succeeded while running")
+	}
+	c.ok("pause", map[string]int{"threadId": threadID}, nil)
+	c.expectStop("pause", "loop", 2)
+	n := c.evaluate("n", 1)
+
+	// 止まっている間は進まない
+	if again := c.evaluate("n", 1); again != n {
+		t.Errorf("n changed from %s to %s while paused", n, again)
+	}
+	c.ok("disconnect", nil, nil)
+	c.event("exited", nil)
+	c.event("terminated", nil)
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serve error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the server did not stop after disconnect")
+	}
+}
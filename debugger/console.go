@@ -0,0 +1,188 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const consoleHelp = `commands:
+  break N, b N       set a breakpoint at line N
+  clear N            remove the breakpoint at line N
+  breakpoints        list breakpoints
+  continue, c        run until the next breakpoint
+  step, s            step into the next statement
+  next, n            step over function calls
+  out, o             run until the current function returns
+  stack, bt          print the call stack
+  frame N, f N       select frame N of the stack
+  vars [N], v [N]    print the variables of the selected frame (or frame N)
+  print EXPR, p EXPR evaluate EXPR in the selected frame
+  quit, q            stop the program
+An empty line repeats the previous command.
+`
+
+// Console はコマンドを一行ずつ読んでデバッガを操作する Frontend
+type Console struct {
+	in      *bufio.Scanner
+	out     io.Writer
+	source  []string
+	frame   int    // 選んでいるフレーム(Stack の番号)
+	command string // 前に入力したコマンド
+}
+
+// NewConsole は in からコマンドを読み、out に結果を書く Console を作る
+// src は止まった行を表示するためのスクリプトのソース
+func NewConsole(in io.Reader, out io.Writer, src string) *Console {
+	return &Console{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		source: strings.Split(src, "\n"),
+	}
+}
+
+// Stopped は Frontend の実装
+// 実行を再開するコマンドか quit を受け取るまでコマンドを読み続ける
+// 入力が終わったら quit と同じく実行を止める
+func (c *Console) Stopped(d *Debugger, reason StopReason) {
+	c.frame = 0
+	top, _ := d.Frame(0)
+	fmt.Fprintf(c.out, "stopped (%s) in %s at line %d\n", reason, top.Function, top.Line)
+	c.printLine(top.Line)
+	for {
+		io.WriteString(c.out, "(onu) ")
+		if !c.in.Scan() {
+			io.WriteString(c.out, "\n")
+			d.Terminate()
+			return
+		}
+		line := strings.TrimSpace(c.in.Text())
+		if line == "" {
+			line = c.command
+		}
+		c.command = line
+		if c.execute(d, line) {
+			return
+		}
+	}
+}
+
+// execute は一つのコマンドを実行し、実行を再開するなら true を返す
+func (c *Console) execute(d *Debugger, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "":
+	case "break", "b":
+		if n, ok := c.lineNumber(arg); ok {
+			d.SetBreakpoint(n)
+			fmt.Fprintf(c.out, "breakpoint at line %d\n", n)
+		}
+	case "clear":
+		if n, ok := c.lineNumber(arg); ok {
+			d.ClearBreakpoint(n)
+		}
+	case "breakpoints":
+		for _, n := range d.Breakpoints() {
+			fmt.Fprintf(c.out, "line %d\n", n)
+		}
+	case "continue", "c":
+		d.Continue()
+		return true
+	case "step", "s":
+		d.StepIn()
+		return true
+	case "next", "n":
+		d.StepOver()
+		return true
+	case "out", "o":
+		d.StepOut()
+		return true
+	case "stack", "bt":
+		c.printStack(d)
+	case "frame", "f":
+		if n, ok := c.frameNumber(d, arg); ok {
+			c.frame = n
+			f, _ := d.Frame(n)
+			fmt.Fprintf(c.out, "#%d %s at line %d\n", n, f.Function, f.Line)
+		}
+	case "vars", "v":
+		n := c.frame
+		if arg != "" {
+			var ok bool
+			if n, ok = c.frameNumber(d, arg); !ok {
+				return false
+			}
+		}
+		f, _ := d.Frame(n)
+		c.printScopes(d, f)
+	case "print", "p":
+		if arg == "" {
+			io.WriteString(c.out, "usage: print EXPR\n")
+			return false
+		}
+		f, _ := d.Frame(c.frame)
+		result, err := d.Evaluate(f, arg)
+		if err != nil {
+			fmt.Fprintf(c.out, "%v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.out, "%s\n", Describe(result))
+	case "quit", "q":
+		d.Terminate()
+		return true
+	case "help", "h":
+		io.WriteString(c.out, consoleHelp)
+	default:
+		fmt.Fprintf(c.out, "unknown command %q (type help for a list of commands)\n", name)
+	}
+	return false
+}
+
+func (c *Console) lineNumber(arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		fmt.Fprintf(c.out, "invalid line number %q\n", arg)
+		return 0, false
+	}
+	return n, true
+}
+
+func (c *Console) frameNumber(d *Debugger, arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if _, ok := d.Frame(n); err != nil || !ok {
+		fmt.Fprintf(c.out, "no frame %q\n", arg)
+		return 0, false
+	}
+	return n, true
+}
+
+func (c *Console) printLine(line int) {
+	if line >= 1 && line <= len(c.source) {
+		fmt.Fprintf(c.out, "%4d | %s\n", line, c.source[line-1])
+	}
+}
+
+func (c *Console) printStack(d *Debugger) {
+	for i, f := range d.Stack() {
+		marker := " "
+		if i == c.frame {
+			marker = "*"
+		}
+		fmt.Fprintf(c.out, "%s#%d %s at line %d\n", marker, i, f.Function, f.Line)
+	}
+}
+
+func (c *Console) printScopes(d *Debugger, f *Frame) {
+	for _, scope := range d.Scopes(f) {
+		if len(scope.Variables) == 0 {
+			continue
+		}
+		fmt.Fprintf(c.out, "%s:\n", scope.Name)
+		for _, v := range scope.Variables {
+			fmt.Fprintf(c.out, "  %s = %s\n", v.Name, Describe(v.Value))
+		}
+	}
+}
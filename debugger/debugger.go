@@ -0,0 +1,381 @@
+// Package debugger は構文木の評価器で動くスクリプトを一時停止して調べるデバッガ
+//
+// 行ブレークポイント、ステップ実行(step in/over/out)、呼び出しスタックと各フレームの変数の表示、
+// 一時停止したフレームでの式の評価ができる
+// 一時停止したときの操作は Frontend が行う(コマンドラインの Console など)
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/onu"
+)
+
+// StopReason は一時停止した理由
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"      // 最初の文の前
+	StopBreakpoint StopReason = "breakpoint" // ブレークポイントのある行
+	StopStep       StopReason = "step"       // ステップ実行が終わった
+	StopPause      StopReason = "pause"      // Pause で止めた
+)
+
+// Frontend は一時停止したときに呼ばれる
+// Stopped から戻ると、それまでに Continue や StepIn などで指定したとおりに実行を再開する
+type Frontend interface {
+	Stopped(d *Debugger, reason StopReason)
+}
+
+// Frame は呼び出し中の関数一つ
+type Frame struct {
+	Function string              // 関数名(トップレベルは <main>)
+	Line     int                 // 実行中の文の行番号
+	Env      *object.Environment // 実行中の文のスコープ
+}
+
+// Scope はフレームから見える変数の集まり
+type Scope struct {
+	Name      string
+	Variables []Variable
+}
+
+// Variable は変数の名前と値
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+type stepMode int
+
+const (
+	run      stepMode = iota // 次のブレークポイントまで実行する
+	stepIn                   // 次の文で止まる
+	stepOver                 // 同じか外側のフレームの次の文で止まる
+	stepOut                  // 外側のフレームの次の文で止まる
+)
+
+// Debugger は evaluator.Debugger のフックで実行を止める
+// ブレークポイントの設定と Pause は実行中に別のゴルーチンから呼んでもよい
+// それ以外のメソッドは一時停止している間だけ使える
+type Debugger struct {
+	interpreter *onu.Interpreter
+	frontend    Frontend
+	filename    string
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool
+	terminated  bool
+	cancel      context.CancelFunc
+
+	frames     []*Frame
+	mode       stepMode
+	stepDepth  int           // ステップ実行を始めたときのフレームの数
+	stepFrom   ast.Statement // ステップ実行を始めたときの文
+	current    ast.Statement // 止まっている文
+	evaluating bool          // Evaluate で式を評価している間は止まらない
+
+	evaluateTimeout time.Duration
+}
+
+// DefaultEvaluateTimeout は Evaluate で一つの式の評価にかけてよい時間の既定値
+const DefaultEvaluateTimeout = 5 * time.Second
+
+// New は interpreter にフックを設定したデバッガを作る
+// stopOnEntry なら最初の文の前で止まる
+func New(interpreter *onu.Interpreter, frontend Frontend, stopOnEntry bool) *Debugger {
+	d := &Debugger{
+		interpreter:     interpreter,
+		frontend:        frontend,
+		breakpoints:     make(map[int]bool),
+		evaluateTimeout: DefaultEvaluateTimeout,
+	}
+	if stopOnEntry {
+		d.mode = stepIn
+	}
+	interpreter.SetBackend(onu.TreeWalker)
+	interpreter.SetDebugger(d)
+	return d
+}
+
+// Run は filename のスクリプトをデバッガの下で実行する
+// Terminate で止めたときは、取り消されたことを表す *onu.RuntimeError を返す
+func (d *Debugger) Run(ctx context.Context, filename, src string) (object.Object, error) {
+	program, err := d.interpreter.Compile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+	d.filename = filename
+	d.frames = []*Frame{{Function: "<main>"}}
+	defer func() { d.frames = nil }()
+	return d.interpreter.RunProgram(ctx, filename, program)
+}
+
+// Terminate は実行を取り消し、それ以降は一時停止しないようにする
+// スクリプトは次の文を実行する前に止まる
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminated = true
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Terminated は Terminate で実行を止めたかどうかを返す
+func (d *Debugger) Terminated() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.terminated
+}
+
+// SetBreakpoint は line にブレークポイントを設定する
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint は line のブレークポイントを取り除く
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// ClearBreakpoints はすべてのブレークポイントを取り除く
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool)
+}
+
+// Breakpoints はブレークポイントのある行を小さい順に返す
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Pause は次の文の前で実行を止める
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = true
+}
+
+// Continue は次のブレークポイントまで実行する
+func (d *Debugger) Continue() {
+	d.mode = run
+}
+
+// StepIn は次の文まで実行する(関数の呼び出しがあればその中で止まる)
+func (d *Debugger) StepIn() {
+	d.mode, d.stepDepth, d.stepFrom = stepIn, len(d.frames), d.current
+}
+
+// StepOver は関数の呼び出しを飛ばして、同じフレームの次の文まで実行する
+func (d *Debugger) StepOver() {
+	d.mode, d.stepDepth, d.stepFrom = stepOver, len(d.frames), d.current
+}
+
+// StepOut は今の関数から戻るまで実行する
+func (d *Debugger) StepOut() {
+	d.mode, d.stepDepth, d.stepFrom = stepOut, len(d.frames), d.current
+}
+
+// Stack は呼び出し中の関数を内側から順に返す
+func (d *Debugger) Stack() []*Frame {
+	stack := make([]*Frame, len(d.frames))
+	for i, f := range d.frames {
+		stack[len(d.frames)-1-i] = f
+	}
+	return stack
+}
+
+// Frame は Stack の n 番目のフレームを返す
+func (d *Debugger) Frame(n int) (*Frame, bool) {
+	if n < 0 || n >= len(d.frames) {
+		return nil, false
+	}
+	return d.frames[len(d.frames)-1-n], true
+}
+
+// Scopes はフレームから見える変数をローカルとグローバルに分けて返す
+// ローカルは内側のスコープから順にたどり、外側の同じ名前の変数は隠れているので含めない
+func (d *Debugger) Scopes(frame *Frame) []Scope {
+	locals := Scope{Name: "Locals"}
+	seen := make(map[string]bool)
+	env := frame.Env
+	for ; env != nil && env.Outer() != nil; env = env.Outer() {
+		locals.Variables = append(locals.Variables, variables(env, seen)...)
+	}
+	scopes := []Scope{locals}
+	if env != nil {
+		scopes = append(scopes, Scope{Name: "Globals", Variables: variables(env, seen)})
+	}
+	return scopes
+}
+
+func variables(env *object.Environment, seen map[string]bool) []Variable {
+	var vars []Variable
+	for _, name := range env.Names() {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		value, _ := env.Get(name)
+		vars = append(vars, Variable{Name: name, Value: value})
+	}
+	return vars
+}
+
+// Evaluate は src をフレームのスコープで評価する
+// 評価している間はブレークポイントやステップ実行では止まらない
+// エラーは <expr> の中の位置として報告する
+// 終わらない式でセッションが止まらないよう、SetEvaluateTimeout の時間を過ぎたら取り消す
+func (d *Debugger) Evaluate(frame *Frame, src string) (object.Object, error) {
+	d.evaluating = true
+	defer func() { d.evaluating = false }()
+	ctx, cancel := context.WithTimeout(context.Background(), d.evaluateTimeout)
+	defer cancel()
+	return d.interpreter.EvalIn(ctx, "<expr>", src, frame.Env)
+}
+
+// SetEvaluateTimeout は Evaluate で一つの式の評価にかけてよい時間を設定する
+func (d *Debugger) SetEvaluateTimeout(timeout time.Duration) {
+	d.evaluateTimeout = timeout
+}
+
+// BeforeStatement は evaluator.Debugger の実装
+func (d *Debugger) BeforeStatement(node ast.Statement, env *object.Environment) {
+	if d.evaluating || len(d.frames) == 0 {
+		return
+	}
+	top := d.frames[len(d.frames)-1]
+	top.Line, top.Env = node.Line(), env
+
+	if reason, ok := d.shouldStop(node); ok {
+		d.mode, d.current = run, node
+		d.frontend.Stopped(d, reason)
+		d.current = nil
+	}
+}
+
+func (d *Debugger) shouldStop(node ast.Statement) (StopReason, bool) {
+	d.mu.Lock()
+	pause, breakpoint, terminated := d.pause, d.breakpoints[node.Line()], d.terminated
+	d.pause = false
+	d.mu.Unlock()
+
+	depth := len(d.frames)
+	switch {
+	case terminated:
+		return "", false
+	case pause:
+		return StopPause, true
+	case d.mode == stepIn && d.stepDepth == 0:
+		return StopEntry, true
+	case d.mode != run && depth == d.stepDepth && nested(d.stepFrom, node):
+		// if (x) { return 1 } のように同じ行にある内側の文では止まらない
+		return "", false
+	case d.mode == stepIn,
+		d.mode == stepOver && depth <= d.stepDepth,
+		d.mode == stepOut && depth < d.stepDepth:
+		return StopStep, true
+	case breakpoint:
+		return StopBreakpoint, true
+	}
+	return "", false
+}
+
+// nested は node が outer の内側にあって同じ行から始まるかを返す
+// ループで同じ文をもう一度実行するときは止まれるよう、outer 自身は含めない
+func nested(outer, node ast.Statement) bool {
+	if outer == nil || outer == node || outer.Line() != node.Line() {
+		return false
+	}
+	found := false
+	ast.Inspect(outer, func(n ast.Node) bool {
+		if n == ast.Node(node) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// EnterFunction は evaluator.Debugger の実装
+func (d *Debugger) EnterFunction(fn *object.Function, env *object.Environment) {
+	if d.evaluating || len(d.frames) == 0 {
+		return
+	}
+	d.frames = append(d.frames, &Frame{Function: fn.DisplayName(), Env: env})
+}
+
+// LeaveFunction は evaluator.Debugger の実装
+func (d *Debugger) LeaveFunction(fn *object.Function, result object.Object) {
+	if d.evaluating || len(d.frames) == 0 {
+		return
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+// Describe は値を一行で表示する
+// 文字列は引用符で囲み、関数は本体を省く
+func Describe(value object.Object) string {
+	switch value := value.(type) {
+	case *object.String:
+		return strconv.Quote(value.Value)
+	case *object.Function:
+		return "func " + value.DisplayName()
+	case nil:
+		return "nil"
+	}
+	return value.String()
+}
+
+// Children は配列の要素、ハッシュの値、インスタンスのフィールドを返す
+// 中を展開できない値なら nil を返す
+func Children(value object.Object) []Variable {
+	var children []Variable
+	switch value := value.(type) {
+	case *object.Array:
+		for i, element := range value.Elements {
+			children = append(children, Variable{Name: fmt.Sprintf("[%d]", i), Value: element})
+		}
+	case *object.Hash:
+		for _, key := range value.Keys() {
+			element, _ := value.Get(key.(object.Hashable))
+			children = append(children, Variable{Name: "[" + Describe(key) + "]", Value: element})
+		}
+	case *object.Instance:
+		names := make([]string, 0, len(value.Fields))
+		for name := range value.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			children = append(children, Variable{Name: name, Value: value.Fields[name]})
+		}
+	}
+	return children
+}
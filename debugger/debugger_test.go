@@ -0,0 +1,228 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/onu"
+)
+
+const program = `func add(a, b) {
+  var sum = a + b
+  return sum
+}
+func twice(x) {
+  var y = add(x, x)
+  return y
+}
+var result = twice(3)
+print result`
+
+// scripted は止まった位置を記録し、決められた順に操作する Frontend
+type scripted struct {
+	actions []func(d *Debugger)
+	stops   []string
+}
+
+func (s *scripted) Stopped(d *Debugger, reason StopReason) {
+	top, _ := d.Frame(0)
+	s.stops = append(s.stops, fmt.Sprintf("%s %s:%d", reason, top.Function, top.Line))
+	if len(s.actions) == 0 {
+		d.Continue()
+		return
+	}
+	s.actions[0](d)
+	s.actions = s.actions[1:]
+}
+
+func debug(t *testing.T, frontend Frontend, stopOnEntry bool, breakpoints ...int) (*Debugger, string) {
+	t.Helper()
+	var out strings.Builder
+	i := onu.New()
+	i.SetStdout(&out)
+	d := New(i, frontend, stopOnEntry)
+	for _, line := range breakpoints {
+		d.SetBreakpoint(line)
+	}
+	if _, err := d.Run(context.Background(), "test.onu", program); err != nil && !d.Terminated() {
+		t.Fatalf("run error: %v", err)
+	}
+	return d, out.String()
+}
+
+func TestStepping(t *testing.T) {
+	stepIn := func(d *Debugger) { d.StepIn() }
+	stepOver := func(d *Debugger) { d.StepOver() }
+	stepOut := func(d *Debugger) { d.StepOut() }
+	tests := []struct {
+		name        string
+		actions     []func(d *Debugger)
+		breakpoints []int
+		expected    []string
+	}{
+		{
+			name:     "step in",
+			actions:  []func(d *Debugger){stepIn, stepIn, stepIn, stepIn, stepIn, stepIn, stepIn, stepIn},
+			expected: []string{"entry <main>:1", "step <main>:5", "step <main>:9", "step twice:6", "step add:2", "step add:3", "step twice:7", "step <main>:10"},
+		},
+		{
+			name:     "step over",
+			actions:  []func(d *Debugger){stepOver, stepOver, stepOver},
+			expected: []string{"entry <main>:1", "step <main>:5", "step <main>:9", "step <main>:10"},
+		},
+		{
+			name:        "step out",
+			actions:     []func(d *Debugger){stepOut, stepOut},
+			breakpoints: []int{2},
+			expected:    []string{"breakpoint add:2", "step twice:7", "step <main>:10"},
+		},
+		{
+			name:        "continue",
+			breakpoints: []int{3, 7},
+			expected:    []string{"breakpoint add:3", "breakpoint twice:7"},
+		},
+	}
+	for _, tt := range tests {
+		frontend := &scripted{actions: tt.actions}
+		_, out := debug(t, frontend, len(tt.breakpoints) == 0, tt.breakpoints...)
+		if strings.Join(frontend.stops, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("%s: got stops %v, want %v", tt.name, frontend.stops, tt.expected)
+		}
+		if out != "6\n" {
+			t.Errorf("%s: got output %q, want %q", tt.name, out, "6\n")
+		}
+	}
+}
+
+func TestInspectFrames(t *testing.T) {
+	var stack []string
+	var locals, evaluated string
+	frontend := &scripted{actions: []func(d *Debugger){func(d *Debugger) {
+		for _, f := range d.Stack() {
+			stack = append(stack, fmt.Sprintf("%s:%d", f.Function, f.Line))
+		}
+		// 呼び出し元のフレームの変数と、そのフレームでの式の評価
+		caller, _ := d.Frame(1)
+		for _, v := range d.Scopes(caller)[0].Variables {
+			locals += fmt.Sprintf("%s=%s ", v.Name, v.Value)
+		}
+		result, err := d.Evaluate(caller, "x * add(x, 1)")
+		if err != nil {
+			t.Fatalf("evaluate error: %v", err)
+		}
+		evaluated = result.String()
+	}}}
+	_, out := debug(t, frontend, false, 3)
+
+	if got := strings.Join(stack, " "); got != "add:3 twice:6 <main>:9" {
+		t.Errorf("got stack %q", got)
+	}
+	if locals != "x=3 " {
+		t.Errorf("got locals %q, want %q", locals, "x=3 ")
+	}
+	// 評価中に呼んだ関数ではブレークポイントで止まらない
+	if evaluated != "12" || len(frontend.stops) != 1 {
+		t.Errorf("got %s with stops %v", evaluated, frontend.stops)
+	}
+	if out != "6\n" {
+		t.Errorf("got output %q", out)
+	}
+}
+
+// 式の評価のエラーは一時停止しているスタックを含まず、式の中の位置を指す
+func TestEvaluateErrors(t *testing.T) {
+	var errs []string
+	frontend := &scripted{actions: []func(d *Debugger){func(d *Debugger) {
+		top, _ := d.Frame(0)
+		for _, src := range []string{"a + nil", "\n(1 +", "missing"} {
+			if _, err := d.Evaluate(top, src); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(d.Stack()) != 3 {
+			t.Errorf("evaluation changed the stack: %v", d.Stack())
+		}
+	}}}
+	_, out := debug(t, frontend, false, 2)
+
+	expected := []string{
+		"<expr>: ERROR: unknown operator: INTEGER + NIL\nTraceback (most recent call last):\n  line 1, in <main>",
+		"<expr>: line 2; no prefix parse function for \n<expr>: line 2; expected ')', but got ",
+		"<expr>: ERROR: undefined identifier missing\nTraceback (most recent call last):\n  line 1, in <main>",
+	}
+	if strings.Join(errs, "|") != strings.Join(expected, "|") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(errs, "\n---\n"), strings.Join(expected, "\n---\n"))
+	}
+	if out != "6\n" {
+		t.Errorf("got output %q", out)
+	}
+}
+
+// 終わらない式の評価は時間切れで取り消され、一時停止した実行はそのまま続けられる
+func TestEvaluateTimeout(t *testing.T) {
+	var evalErr error
+	frontend := &scripted{actions: []func(d *Debugger){func(d *Debugger) {
+		d.SetEvaluateTimeout(50 * time.Millisecond)
+		top, _ := d.Frame(0)
+		_, evalErr = d.Evaluate(top, "for (a in range(100000)) { for (b in range(100000)) { a + b } }")
+	}}}
+	_, out := debug(t, frontend, false, 2)
+
+	if evalErr == nil || !strings.Contains(evalErr.Error(), evaluator.ExecutionCancelled) {
+		t.Errorf("expected %q, but got %v", evaluator.ExecutionCancelled, evalErr)
+	}
+	if out != "6\n" {
+		t.Errorf("got output %q", out)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	commands := strings.Join([]string{
+		"b 2",
+		"c",
+		"bt",
+		"p a + b",
+		"p",
+		"vars 1",
+		"n",
+		"",
+		"q",
+	}, "\n")
+	var transcript strings.Builder
+	console := NewConsole(strings.NewReader(commands), &transcript, program)
+	d, out := debug(t, console, true)
+
+	for _, expected := range []string{
+		"stopped (entry) in <main> at line 1\n   1 | func add(a, b) {",
+		"breakpoint at line 2",
+		"stopped (breakpoint) in add at line 2\n   2 |   var sum = a + b",
+		"*#0 add at line 2\n #1 twice at line 6\n #2 <main> at line 9",
+		"(onu) 6\n",
+		"(onu) usage: print EXPR\n",
+		"Locals:\n  x = 3\nGlobals:\n  add = func add\n  twice = func twice\n",
+		"stopped (step) in add at line 3",
+		// 空行は前のコマンドを繰り返す
+		"stopped (step) in twice at line 7",
+	} {
+		if !strings.Contains(transcript.String(), expected) {
+			t.Errorf("transcript does not contain %q:\n%s", expected, transcript.String())
+		}
+	}
+	// quit で止めたので、それ以降の print は実行されない
+	if !d.Terminated() || out != "" {
+		t.Errorf("got output %q after quit", out)
+	}
+}
+
+func TestConsoleEndOfInput(t *testing.T) {
+	console := NewConsole(strings.NewReader("c\n"), io.Discard, program)
+	d, out := debug(t, console, false, 3, 7)
+	if !d.Terminated() || out != "" {
+		t.Errorf("got output %q, want the program to stop", out)
+	}
+}
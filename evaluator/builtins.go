@@ -0,0 +1,281 @@
+package evaluator
+
+import (
+	"bufio"
+	"go-interpreter-practice/object"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 入出力を使わない組み込み関数
+// 入出力を使うもの(write, println, eprintln, input)は Evaluator ごとに registerBuiltins で登録する
+var builtins = map[string]object.BuiltinFunction{
+	"len":    builtinLen,
+	"type":   builtinType,
+	"str":    builtinStr,
+	"int":    builtinInt,
+	"float":  builtinFloat,
+	"assert": builtinAssert,
+}
+
+// RegisterBuiltin は組み込み関数を登録する
+// 同じ名前の変数が宣言されていなければ、スクリプトから name で呼び出せる
+func (e *Evaluator) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	e.builtins[name] = &object.Builtin{Name: name, Fn: fn}
+}
+
+// RegisterModule はモジュールを登録する
+// 組み込み関数と同じく、同じ名前の変数が宣言されていなければ m.Name で参照できる
+func (e *Evaluator) RegisterModule(m *object.Module) {
+	e.builtins[m.Name] = m
+}
+
+// HasBuiltin は name の組み込み関数かモジュールが登録されているかを返す
+func (e *Evaluator) HasBuiltin(name string) bool {
+	_, ok := e.builtins[name]
+	return ok
+}
+
+// Builtin は name の組み込み関数かモジュールを返す
+func (e *Evaluator) Builtin(name string) (object.Object, bool) {
+	builtin, ok := e.builtins[name]
+	return builtin, ok
+}
+
+// SetInput は input 関数の読み込み元を設定する
+func (e *Evaluator) SetInput(in io.Reader) {
+	e.in = bufio.NewReader(in)
+}
+
+// SetOutput は print 文と write, println 関数の出力先を設定する
+func (e *Evaluator) SetOutput(out io.Writer) {
+	e.out = out
+}
+
+// SetErrorOutput は eprintln 関数の出力先を設定する
+func (e *Evaluator) SetErrorOutput(errOut io.Writer) {
+	e.errOut = errOut
+}
+
+func (e *Evaluator) registerBuiltins() {
+	e.builtins = make(map[string]object.Object)
+	for name, fn := range builtins {
+		e.RegisterBuiltin(name, fn)
+	}
+	e.RegisterBuiltin("write", e.builtinWrite)
+	e.RegisterBuiltin("println", e.builtinPrintln)
+	e.RegisterBuiltin("eprintln", e.builtinEprintln)
+	e.RegisterBuiltin("input", e.builtinInput)
+	e.RegisterBuiltin("range", e.builtinRange)
+}
+
+func arityError(name string, want string, got int) *object.Error {
+	return object.NewError("wrong number of arguments: %s expects %s, got %d", name, want, got)
+}
+
+func argumentError(name string, arg object.Object) *object.Error {
+	return object.NewError("%s: unsupported argument type %s", name, arg.Type())
+}
+
+// len(x) は文字列の文字数、配列の要素数、ハッシュのキーの数を返す
+func builtinLen(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return arityError("len", "1", len(args))
+	}
+	switch arg := args[0].(type) {
+	case *object.String:
+		return object.NewInteger(utf8.RuneCountInString(arg.Value))
+	case *object.Array:
+		return object.NewInteger(len(arg.Elements))
+	case *object.Hash:
+		return object.NewInteger(len(arg.Pairs))
+	}
+	return argumentError("len", args[0])
+}
+
+// type(x) は型の名前を小文字で返す
+func builtinType(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return arityError("type", "1", len(args))
+	}
+	return object.NewString(strings.ToLower(string(args[0].Type())))
+}
+
+func builtinStr(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return arityError("str", "1", len(args))
+	}
+	return object.NewString(args[0].String())
+}
+
+// int(x) は小数を 0 方向に切り捨て、文字列は10進数として読む
+func builtinInt(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return arityError("int", "1", len(args))
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return arg
+	case *object.Float:
+		if math.IsNaN(arg.Value) || arg.Value < math.MinInt64 || arg.Value >= math.MaxInt64 {
+			return object.NewError("int: %s is out of integer range", arg.String())
+		}
+		return object.NewInteger(int(arg.Value))
+	case *object.String:
+		value, err := strconv.Atoi(strings.TrimSpace(arg.Value))
+		if err != nil {
+			return object.NewError("int: cannot convert %q to integer", arg.Value)
+		}
+		return object.NewInteger(value)
+	case *object.Boolean:
+		if arg.Value {
+			return object.NewInteger(1)
+		}
+		return object.NewInteger(0)
+	}
+	return argumentError("int", args[0])
+}
+
+func builtinFloat(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return arityError("float", "1", len(args))
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return object.NewFloat(float64(arg.Value))
+	case *object.Float:
+		return arg
+	case *object.String:
+		value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+		if err != nil {
+			return object.NewError("float: cannot convert %q to float", arg.Value)
+		}
+		return object.NewFloat(value)
+	}
+	return argumentError("float", args[0])
+}
+
+// assert(condition, message) は condition が偽ならエラーを返す
+func builtinAssert(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return arityError("assert", "1 to 2", len(args))
+	}
+	if args[0].IsTruthy() {
+		return object.NewNil()
+	}
+	if len(args) == 2 {
+		return object.NewError("assertion failed: %s", args[1].String())
+	}
+	return object.NewError("assertion failed")
+}
+
+// write(a, b, ...) は引数を空白区切りで出力する
+// print は文のキーワードなので、改行を付けない出力は別の名前にする
+func (e *Evaluator) builtinWrite(args ...object.Object) object.Object {
+	if err := e.WriteOutput(joinArgs(args)); err != nil {
+		return err
+	}
+	return object.NewNil()
+}
+
+// println(a, b, ...) は write と同じだが最後に改行を出力する
+func (e *Evaluator) builtinPrintln(args ...object.Object) object.Object {
+	if err := e.WriteOutput(joinArgs(args) + "\n"); err != nil {
+		return err
+	}
+	return object.NewNil()
+}
+
+// eprintln(a, b, ...) は println と同じ内容をエラー出力に出力する
+func (e *Evaluator) builtinEprintln(args ...object.Object) object.Object {
+	if _, err := io.WriteString(e.errOut, joinArgs(args)+"\n"); err != nil {
+		return object.NewError("eprintln: %v", err)
+	}
+	return object.NewNil()
+}
+
+func joinArgs(args []object.Object) string {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = arg.String()
+	}
+	return strings.Join(values, " ")
+}
+
+// input(prompt) は prompt を出力してから一行読み込み、改行を除いた文字列を返す
+// 入力が終わっていたら nil を返す
+func (e *Evaluator) builtinInput(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return arityError("input", "0 to 1", len(args))
+	}
+	if len(args) == 1 {
+		if err := e.WriteOutput(args[0].String()); err != nil {
+			return err
+		}
+	}
+	line, err := e.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return object.NewNil()
+		}
+		return object.NewError("input: %v", err)
+	}
+	return object.NewString(strings.TrimRight(line, "\r\n"))
+}
+
+// maxRangeLength は range で作れる配列の長さの上限
+const maxRangeLength = 1 << 24
+
+// range(stop), range(start, stop), range(start, stop, step) は整数の配列を返す
+func (e *Evaluator) builtinRange(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return arityError("range", "1 to 3", len(args))
+	}
+	values := make([]int, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return argumentError("range", arg)
+		}
+		values[i] = integer.Value
+	}
+	start, stop, step := 0, values[0], 1
+	if len(values) >= 2 {
+		start, stop = values[0], values[1]
+	}
+	if len(values) == 3 {
+		step = values[2]
+	}
+	if step == 0 {
+		return object.NewError("range: step must not be zero")
+	}
+
+	count := rangeLength(start, stop, step)
+	if count > maxRangeLength {
+		return object.NewError("range: too many elements (%d, limit %d)", count, maxRangeLength)
+	}
+	// 要素と配列自身の分が収まるかを作る前に確かめる(数えるのは呼び出し元)
+	if err := e.checkAllocation(int(count) + 1); err != nil {
+		return err
+	}
+	elements := make([]object.Object, count)
+	for i := range elements {
+		elements[i] = object.NewInteger(start + i*step)
+	}
+	return object.NewArray(elements)
+}
+
+// rangeLength は start から step ずつ進めて stop に届かない値の数を返す
+// stop - start が int に収まらなくてもあふれないよう、符号なしで計算する
+func rangeLength(start, stop, step int) uint64 {
+	switch {
+	case step > 0 && start < stop:
+		return (uint64(stop)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > stop:
+		return (uint64(start)-uint64(stop)-1)/(0-uint64(step)) + 1
+	}
+	return 0
+}
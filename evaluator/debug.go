@@ -0,0 +1,39 @@
+package evaluator
+
+import (
+	"context"
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+)
+
+// Debugger は評価の途中で呼ばれるフック
+// デバッガはこれを使って実行を一時停止したり、呼び出し中の関数の環境を調べたりする
+// 実行を観察するだけなら Hooks を使う
+// フックの中で実行を止めている間は、評価器の他のメソッドを別のゴルーチンから呼ばないこと
+type Debugger interface {
+	// BeforeStatement は文を評価する直前に呼ばれる(ブロック自体は除く)
+	BeforeStatement(node ast.Statement, env *object.Environment)
+	// EnterFunction は関数の本体を実行する直前に、引数を束縛した環境で呼ばれる
+	EnterFunction(fn *object.Function, env *object.Environment)
+	// LeaveFunction は関数の本体を実行し終えたときに呼ばれる
+	// 末尾呼び出しで別の関数に置き換えられたときは result が nil になる
+	LeaveFunction(fn *object.Function, result object.Object)
+}
+
+// SetDebugger は評価に使うフックを設定する
+// nil なら何も呼ばない
+func (e *Evaluator) SetDebugger(d Debugger) {
+	e.debugger = d
+}
+
+// EvalDetached は呼び出し中の関数のスタックから切り離して node を評価する
+// 一時停止している関数の環境で式を評価するためのもので、
+// エラーのスタックトレースには評価した式の中の位置だけが入る
+// ctx と limits は EvalContext と同じように効き、一時停止している実行の ctx と上限も引き続き守る
+func (e *Evaluator) EvalDetached(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) object.Object {
+	defer e.WithContext(ctx, limits)()
+	frames := e.frames
+	e.frames = nil
+	defer func() { e.frames = frames }()
+	return e.Eval(node, env)
+}
@@ -1,91 +1,191 @@
 package evaluator
 
 import (
+	"bufio"
+	"context"
+	"fmt"
 	"go-interpreter-practice/ast"
 	"go-interpreter-practice/object"
+	"io"
+	"math"
+	"os"
 )
 
+// DefaultMaxCallDepth は関数呼び出しのネストの上限の初期値
+// Go のスタックを使い切る前にエラーにできるよう、十分に小さくしておく
+const DefaultMaxCallDepth = 10000
+
+type Evaluator struct {
+	out          io.Writer     // print 文の出力先
+	errOut       io.Writer     // eprint 関数の出力先
+	in           *bufio.Reader // input 関数の読み込み元
+	frames       []frame       // 呼び出し中の関数のスタック
+	maxCallDepth int
+	builtins     map[string]object.Object // 組み込み関数とモジュール
+	debugger     Debugger
+	hooks        Hooks
+
+	// EvalContext で実行しているときだけ設定される
+	ctx     context.Context
+	limits  Limits
+	usage   usage
+	running int // WithContext で始めた実行の入れ子の深さ
+}
+
+type frame struct {
+	function string // 呼び出された関数の名前
+	callLine int    // 呼び出し元の行番号
+}
+
+func NewEvaluator(out io.Writer) *Evaluator {
+	e := &Evaluator{
+		out:          out,
+		errOut:       os.Stderr,
+		in:           bufio.NewReader(os.Stdin),
+		maxCallDepth: DefaultMaxCallDepth,
+	}
+	e.registerBuiltins()
+	return e
+}
+
+// SetMaxCallDepth は関数呼び出しのネストの上限を設定する
+func (e *Evaluator) SetMaxCallDepth(depth int) {
+	e.maxCallDepth = depth
+}
+
+// MaxCallDepth は関数呼び出しのネストの上限を返す
+func (e *Evaluator) MaxCallDepth() int {
+	return e.maxCallDepth
+}
+
+// Eval は標準出力に出力する Evaluator で node を評価する
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return NewEvaluator(os.Stdout).Eval(node, env)
+}
+
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	if err := e.Step(); err != nil {
+		return e.fail(err, node, env)
+	}
+	if e.debugger != nil {
+		if statement, ok := hookedStatement(node); ok {
+			e.debugger.BeforeStatement(statement, env)
+			// 止まっている間に実行が取り消されたかもしれない
+			if err := e.CheckContext(); err != nil {
+				return e.fail(err, node, env)
+			}
+		}
+	}
+	result := e.eval(node, env)
+	if allocates(node) && !isError(result) {
+		if err := e.AllocateObject(result); err != nil {
+			result = err
+		}
+	}
+	// エラーが最初に生まれたノードで、行番号とスタックトレースを付ける
+	if err, ok := result.(*object.Error); ok && err.Stack == nil {
+		e.fail(err, node, env)
+	}
+	if e.hooks != nil {
+		if statement, ok := hookedStatement(node); ok {
+			e.hooks.OnStatement(statement, env, statementValue(result))
+		}
+	}
+	return result
+}
+
+// fail はエラーに node の行番号とスタックトレースを付け、フックに知らせる
+func (e *Evaluator) fail(err *object.Error, node ast.Node, env *object.Environment) *object.Error {
+	e.attachStackTrace(err, node.Line())
+	if e.hooks != nil {
+		e.hooks.OnError(node, env, err)
+	}
+	return err
+}
+
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.Eval(node.Expression, env)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		right := Eval(node.Right, env)
+		left := e.Eval(node.Left, env)
+		right := e.Eval(node.Right, env)
 		if isError(left) {
 			return left
 		}
 		if isError(right) {
 			return right
 		}
-		switch {
-		case object.IsNumber(left) && object.IsNumber(right):
-			if left.Type() == object.FLOAT && right.Type() == object.INTEGER {
-				right = object.NewFloat(float64(right.(*object.Integer).Value))
-				return evalFloatInfixExpression(node.Operator, left, right)
-			}
-			if left.Type() == object.INTEGER && right.Type() == object.FLOAT {
-				left = object.NewFloat(float64(left.(*object.Integer).Value))
-				return evalFloatInfixExpression(node.Operator, left, right)
-			}
-
-			if left.Type() == object.INTEGER && right.Type() == object.INTEGER {
-				return evalIntegerInfixExpression(node.Operator, left, right)
-			}
-
-			if left.Type() == object.FLOAT && right.Type() == object.FLOAT {
-				return evalFloatInfixExpression(node.Operator, left, right)
-			}
-		case left.Type() == object.STRING && right.Type() == object.STRING:
-			return evalStringInfixExpression(node.Operator, left, right)
-		default:
-			return object.NewError("unknown operator: %s %s %s", left.Type(), node.Operator, right.Type())
-		}
+		return evalInfixExpression(node.Operator, left, right)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return e.evalBlockStatement(node, env)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := e.Eval(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.VarStatement:
-		val := Eval(node.Value, env)
+		val := e.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
+		if env.HasOwn(node.Name.Value) {
+			return redeclarationError(node.Name)
+		}
+		// var sum = func(...) {...} のような無名関数には変数名を付けておく
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			if _, isLiteral := node.Value.(*ast.FunctionExpression); isLiteral {
+				fn.Name = node.Name.Value
+			}
+		}
 		env.Set(node.Name.Value, val)
 	case *ast.Identifier:
-		val, ok := env.Get(node.Value)
-		if !ok {
-			return object.NewError("undefined identifier %v", node.Value)
+		// resolver が位置を求めていればそこから読む
+		// まだ宣言されていない変数などで見つからなければ名前で探し直す
+		if node.Resolved {
+			if val, ok := env.GetAt(node.Depth, node.Slot, node.Value); ok {
+				return val
+			}
 		}
-		return val
+		if val, ok := env.Get(node.Value); ok {
+			return val
+		}
+		// 変数が見つからなければ組み込み関数とモジュールを探す
+		if builtin, ok := e.builtins[node.Value]; ok {
+			return builtin
+		}
+		return object.NewError("undefined identifier %v", node.Value)
 	case *ast.FunctionExpression:
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return newFunction(node, env)
+	case *ast.FunctionStatement:
+		// 関数宣言は evalProgram / evalBlockStatement で巻き上げ済み
+		return nil
 	case *ast.CallExpression:
-		function := Eval(*node.Function, env)
+		function := e.Eval(*node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
+		args := e.evalExpressions(node.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		// 関数の中の末尾呼び出しはその場で実行せず、呼び出し元の applyFunction に任せる
+		if node.Tail && len(e.frames) > 0 {
+			return &tailCall{fn: function, args: args, node: node, env: env}
+		}
+		return e.applyFunction(function, args, node.Token.Line)
 	case *ast.IntegerLiteral:
 		return object.NewInteger(node.Value)
 	case *ast.FloatLiteral:
@@ -94,25 +194,91 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return object.NewBoolean(node.Value)
 	case *ast.StringLiteral:
 		return object.NewString(node.Value)
+	case *ast.NilLiteral:
+		return object.NewNil()
+	case *ast.ArrayLiteral:
+		elements := e.evalExpressionList(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return object.NewArray(elements)
+	case *ast.HashLiteral:
+		return e.evalHashLiteral(node, env)
+	case *ast.IndexExpression:
+		left := e.Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := e.Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.AssignExpression:
+		return e.evalAssignExpression(node, env)
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+	case *ast.PrintStatement:
+		val := e.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := e.WriteOutput(val.String() + "\n"); err != nil {
+			return err
+		}
+		return nil
+	case *ast.ClassStatement:
+		return e.evalClassStatement(node, env)
+	case *ast.GetExpression:
+		obj := e.Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalGetExpression(obj, node.Name.Value)
+	case *ast.ThisExpression:
+		this, ok := env.Get("this")
+		if !ok {
+			return object.NewError("'this' used outside of a class")
+		}
+		return this
+	case *ast.SuperExpression:
+		return evalSuperExpression(node, env)
 	}
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+func (e *Evaluator) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
+	if err := hoistFunctions(program.Statements, env); err != nil {
+		return err
+	}
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = e.Eval(statement, env)
 		if result != nil && result.Type() == object.RETURN {
 			return result.(*object.ReturnValue).Value // アンラップ
 		}
+		// エラーが起きたらそこで実行を止める
+		if isError(result) {
+			return result
+		}
 	}
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+// ブロックは自分のスコープを持つ
+// 内側のスコープでは外側と同じ名前を宣言して隠せるが、同じスコープでの再宣言はエラーになる
+func (e *Evaluator) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	return e.evalStatements(block.Statements, object.NewFrame(env, block.Locals))
+}
+
+// 新しいスコープを作らずに文を順に評価する
+func (e *Evaluator) evalStatements(statements []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
-	for _, stmt := range block.Statements {
-		result = Eval(stmt, env)
+	if err := hoistFunctions(statements, env); err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		result = e.Eval(stmt, env)
 		if result != nil && (result.Type() == object.RETURN || result.Type() == object.ERROR) {
 			return result
 		}
@@ -150,6 +316,9 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 
 	if right.Type() == object.INTEGER {
 		value := right.(*object.Integer).Value
+		if value == math.MinInt {
+			return object.NewError("integer overflow: -(%d)", value)
+		}
 		return object.NewInteger(-value)
 	}
 
@@ -161,26 +330,111 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	return object.NewError("unknown operator: -%s", right.Type())
 }
 
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	// == と != はどの型の組み合わせでも使える
+	case operator == "==":
+		return object.NewBoolean(object.Equal(left, right))
+	case operator == "!=":
+		return object.NewBoolean(!object.Equal(left, right))
+	case object.IsNumber(left) && object.IsNumber(right):
+		if left.Type() == object.FLOAT && right.Type() == object.INTEGER {
+			right = object.NewFloat(float64(right.(*object.Integer).Value))
+			return evalFloatInfixExpression(operator, left, right)
+		}
+		if left.Type() == object.INTEGER && right.Type() == object.FLOAT {
+			left = object.NewFloat(float64(left.(*object.Integer).Value))
+			return evalFloatInfixExpression(operator, left, right)
+		}
+		if left.Type() == object.INTEGER && right.Type() == object.INTEGER {
+			return evalIntegerInfixExpression(operator, left, right)
+		}
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING && right.Type() == object.STRING && operator == "+":
+		return evalStringInfixExpression(operator, left, right)
+	}
+	return evalComparisonExpression(operator, left, right)
+}
+
+// Comparable を実装している型同士の大小比較
+func evalComparisonExpression(operator string, left, right object.Object) object.Object {
+	c, ok := left.(object.Comparable)
+	if !ok {
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	result, ok := c.Compare(right)
+	if !ok {
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	switch operator {
+	case "<":
+		return object.NewBoolean(result < 0)
+	case "<=":
+		return object.NewBoolean(result <= 0)
+	case ">":
+		return object.NewBoolean(result > 0)
+	case ">=":
+		return object.NewBoolean(result >= 0)
+	}
+	return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+// 整数演算はオーバーフローするとエラーを返す
+// / と % は負の無限大方向に丸める(床除算)ので、常に a == (a / b) * b + a % b が成り立つ
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 	switch operator {
 	case "+":
-		return object.NewInteger(leftVal + rightVal)
+		result := leftVal + rightVal
+		if (result > leftVal) != (rightVal > 0) {
+			return object.NewError("integer overflow: %d + %d", leftVal, rightVal)
+		}
+		return object.NewInteger(result)
 	case "-":
-		return object.NewInteger(leftVal - rightVal)
+		result := leftVal - rightVal
+		if (result < leftVal) != (rightVal > 0) {
+			return object.NewError("integer overflow: %d - %d", leftVal, rightVal)
+		}
+		return object.NewInteger(result)
 	case "*":
-		return object.NewInteger(leftVal * rightVal)
+		result := leftVal * rightVal
+		if leftVal != 0 && (result/leftVal != rightVal || (leftVal == -1 && rightVal == math.MinInt)) {
+			return object.NewError("integer overflow: %d * %d", leftVal, rightVal)
+		}
+		return object.NewInteger(result)
 	case "/":
-		return object.NewInteger(leftVal / rightVal)
+		if rightVal == 0 {
+			return object.NewError("division by zero")
+		}
+		if leftVal == math.MinInt && rightVal == -1 {
+			return object.NewError("integer overflow: %d / %d", leftVal, rightVal)
+		}
+		quotient := leftVal / rightVal
+		if leftVal%rightVal != 0 && (leftVal < 0) != (rightVal < 0) {
+			quotient--
+		}
+		return object.NewInteger(quotient)
+	case "%":
+		if rightVal == 0 {
+			return object.NewError("modulo by zero")
+		}
+		if rightVal == -1 {
+			return object.NewInteger(0)
+		}
+		remainder := leftVal % rightVal
+		if remainder != 0 && (remainder < 0) != (rightVal < 0) {
+			remainder += rightVal
+		}
+		return object.NewInteger(remainder)
 	case "<":
 		return object.NewBoolean(leftVal < rightVal)
+	case "<=":
+		return object.NewBoolean(leftVal <= rightVal)
 	case ">":
 		return object.NewBoolean(leftVal > rightVal)
-	case "==":
-		return object.NewBoolean(leftVal == rightVal)
-	case "!=":
-		return object.NewBoolean(leftVal != rightVal)
+	case ">=":
+		return object.NewBoolean(leftVal >= rightVal)
 	}
 	return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 }
@@ -196,15 +450,28 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 	case "*":
 		return object.NewFloat(leftVal * rightVal)
 	case "/":
+		if rightVal == 0 {
+			return object.NewError("division by zero")
+		}
 		return object.NewFloat(leftVal / rightVal)
+	case "%":
+		if rightVal == 0 {
+			return object.NewError("modulo by zero")
+		}
+		// 整数と同じく、結果の符号は右辺に合わせる
+		remainder := math.Mod(leftVal, rightVal)
+		if remainder != 0 && (remainder < 0) != (rightVal < 0) {
+			remainder += rightVal
+		}
+		return object.NewFloat(remainder)
 	case "<":
 		return object.NewBoolean(leftVal < rightVal)
+	case "<=":
+		return object.NewBoolean(leftVal <= rightVal)
 	case ">":
 		return object.NewBoolean(leftVal > rightVal)
-	case "==":
-		return object.NewBoolean(leftVal == rightVal)
-	case "!=":
-		return object.NewBoolean(leftVal != rightVal)
+	case ">=":
+		return object.NewBoolean(leftVal >= rightVal)
 	}
 	return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 
@@ -221,17 +488,22 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+func (e *Evaluator) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := e.Eval(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
+	var result object.Object
 	if condition.IsTruthy() {
-		return Eval(ie.Consequence, env)
+		result = e.Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		result = e.Eval(ie.Alternative, env)
+	}
+	// 値を持たない文で終わるブロックも nil になる
+	if result == nil {
+		return object.NewNil()
 	}
-	return object.NewNil()
+	return result
 }
 
 func isError(obj object.Object) bool {
@@ -241,10 +513,10 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evalExpressions(expressions []*ast.Expression, env *object.Environment) []object.Object {
+func (e *Evaluator) evalExpressions(expressions []*ast.Expression, env *object.Environment) []object.Object {
 	var results []object.Object
 	for _, exp := range expressions {
-		evaluated := Eval(*exp, env)
+		evaluated := e.Eval(*exp, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
@@ -253,22 +525,248 @@ func evalExpressions(expressions []*ast.Expression, env *object.Environment) []o
 	return results
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	function, ok := fn.(*object.Function)
+func (e *Evaluator) evalExpressionList(expressions []ast.Expression, env *object.Environment) []object.Object {
+	results := []object.Object{}
+	for _, exp := range expressions {
+		evaluated := e.Eval(exp, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		results = append(results, evaluated)
+	}
+	return results
+}
+
+// line はエラーメッセージ用の呼び出し元の行番号
+func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, line int) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if err := checkArity(fn, len(args), line); err != nil {
+			return err
+		}
+		if len(e.frames) >= e.maxCallDepth {
+			return object.NewError("maximum recursion depth exceeded (%d)", e.maxCallDepth)
+		}
+		if err := e.CheckContext(); err != nil {
+			return err
+		}
+		e.frames = append(e.frames, frame{function: fn.DisplayName(), callLine: line})
+		defer func() { e.frames = e.frames[:len(e.frames)-1] }()
+		return e.runFunction(fn, args)
+	case *object.Class:
+		return e.instantiate(fn, args, line)
+	case *object.Builtin:
+		if err := e.CheckContext(); err != nil {
+			return err
+		}
+		result := fn.Fn(args...)
+		if result == nil {
+			return object.NewNil()
+		}
+		// 組み込み関数が作った値も上限に数える
+		if !isError(result) {
+			if err := e.AllocateObject(result); err != nil {
+				return err
+			}
+		}
+		return result
+	}
+	return object.NewError("not a function %v", fn.Type())
+}
+
+// tailCall は末尾位置での呼び出しを表す
+// 関数本体の評価結果として runFunction まで戻り、そこで実行される
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	node *ast.CallExpression // エラーの位置に使う呼び出し式
+	env  *object.Environment // 呼び出し式を評価した環境
+}
+
+func (tc *tailCall) Type() object.ObjectType {
+	return "TAIL_CALL"
+}
+
+func (tc *tailCall) String() string {
+	return "tail call"
+}
+
+func (tc *tailCall) IsTruthy() bool {
+	return true
+}
+
+// runFunction は applyFunction が積んだフレームの上で関数本体を評価する
+// 本体が末尾呼び出しで終わった場合は、フレームを置き換えてループで続けて実行する(トランポリン)
+// そのため末尾再帰は Go のスタックも呼び出しの深さも伸ばさない
+func (e *Evaluator) runFunction(fn *object.Function, args []object.Object) object.Object {
+	initializer := fn
+	for {
+		extendedEnv, err := e.extendedFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
+		if e.debugger != nil {
+			e.debugger.EnterFunction(fn, extendedEnv)
+		}
+		if e.hooks != nil {
+			e.hooks.OnCall(fn, extendedEnv)
+		}
+		// 関数本体は引数と同じスコープで評価する
+		result := unwrapReturnValue(e.evalStatements(fn.Body.Statements, extendedEnv))
+
+		if call, ok := result.(*tailCall); ok {
+			if next, ok := call.fn.(*object.Function); ok {
+				if e.debugger != nil {
+					e.debugger.LeaveFunction(fn, nil)
+				}
+				if e.hooks != nil {
+					e.hooks.OnReturn(fn, extendedEnv, nil)
+				}
+				if err := checkArity(next, len(call.args), call.node.Line()); err != nil {
+					return e.fail(err, call.node, call.env)
+				}
+				if err := e.CheckContext(); err != nil {
+					return e.fail(err, call.node, call.env)
+				}
+				e.frames[len(e.frames)-1].function = next.DisplayName()
+				fn, args = next, call.args
+				continue
+			}
+			// 関数以外(クラスなど)はスタックを積んで普通に呼び出す
+			result = e.applyFunction(call.fn, call.args, call.node.Line())
+			if err, ok := result.(*object.Error); ok && err.Stack == nil {
+				e.fail(err, call.node, call.env)
+			}
+		}
+
+		// 値を持たない文で終わる関数は nil を返す
+		if result == nil {
+			result = object.NewNil()
+		}
+		if initializer.IsInitializer && !isError(result) {
+			// init は常にインスタンス自身を返す
+			result, _ = initializer.Env.Get("this")
+		}
+		if e.debugger != nil {
+			e.debugger.LeaveFunction(fn, result)
+		}
+		if e.hooks != nil {
+			e.hooks.OnReturn(fn, extendedEnv, result)
+		}
+		return result
+	}
+}
+
+// クラスを呼び出すとインスタンスを作り、init があれば実行する
+func (e *Evaluator) instantiate(class *object.Class, args []object.Object, line int) object.Object {
+	if err := e.Allocate(1); err != nil {
+		return err
+	}
+	instance := object.NewInstance(class)
+	initializer, ok := class.FindMethod("init")
 	if !ok {
-		return object.NewError("not a function %v", fn.Type())
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments: %s expects 0, got %d (called at line %d)", class.Name, len(args), line)
+		}
+		return instance
+	}
+	result := e.applyFunction(initializer.BindMethod(instance), args, line)
+	if isError(result) {
+		return result
+	}
+	return instance
+}
+
+// 名前付きの関数式は、自分自身の名前だけが見える環境を作って再帰できるようにする
+func newFunction(node *ast.FunctionExpression, env *object.Environment) *object.Function {
+	fn := &object.Function{
+		Parameters: node.Parameters,
+		Defaults:   node.Defaults,
+		Rest:       node.Rest,
+		Body:       node.Body,
+		Locals:     node.Locals,
+		Env:        env,
+	}
+	if node.Name != nil {
+		fn.Name = node.Name.Value
+		fn.Env = object.NewEnclosedEnvironment(env)
+		fn.Env.Set(fn.Name, fn)
 	}
-	extendedEnv := extendedFunctionEnv(*function, args)
-	evaluated := Eval(function.Body, extendedEnv)
-	return unwrapReturnValue(evaluated)
+	return fn
 }
 
-func extendedFunctionEnv(fn object.Function, args []object.Object) *object.Environment {
-	extendedEnv := object.NewEnclosedEnvironment(fn.Env)
+// 関数宣言を文の実行より先にスコープへ束縛する
+// これにより宣言より前での呼び出しや、宣言同士の相互再帰ができる
+func hoistFunctions(statements []ast.Statement, env *object.Environment) *object.Error {
+	for _, statement := range statements {
+		declaration, ok := statement.(*ast.FunctionStatement)
+		if !ok {
+			continue
+		}
+		if env.HasOwn(declaration.Name.Value) {
+			return redeclarationError(declaration.Name)
+		}
+		fn := &object.Function{
+			Name:       declaration.Name.Value,
+			Parameters: declaration.Function.Parameters,
+			Defaults:   declaration.Function.Defaults,
+			Rest:       declaration.Function.Rest,
+			Body:       declaration.Function.Body,
+			Locals:     declaration.Function.Locals,
+			Env:        env,
+		}
+		env.Set(fn.Name, fn)
+	}
+	return nil
+}
+
+func redeclarationError(name *ast.Identifier) *object.Error {
+	return object.NewError("%s is already declared in this scope (line %d)", name.Value, name.Token.Line)
+}
+
+func checkArity(fn *object.Function, got int, line int) *object.Error {
+	min, max := fn.MinArity(), len(fn.Parameters)
+	if got >= min && (got <= max || fn.Rest != nil) {
+		return nil
+	}
+	var expected string
+	switch {
+	case fn.Rest != nil:
+		expected = fmt.Sprintf("at least %d", min)
+	case min == max:
+		expected = fmt.Sprintf("%d", min)
+	default:
+		expected = fmt.Sprintf("%d to %d", min, max)
+	}
+	return object.NewError("wrong number of arguments: %s expects %s, got %d (called at line %d)", fn.DisplayName(), expected, got, line)
+}
+
+// 引数を束縛した環境を作る
+// 省略された引数のデフォルト値は、それより前の引数が見える環境で評価する
+func (e *Evaluator) extendedFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, object.Object) {
+	if err := e.Allocate(1); err != nil {
+		return nil, err
+	}
+	extendedEnv := object.NewFrame(fn.Env, fn.Locals)
 	for i, param := range fn.Parameters {
-		extendedEnv.Set(param.Value, args[i])
+		if i < len(args) {
+			extendedEnv.Set(param.Value, args[i])
+			continue
+		}
+		value := e.Eval(fn.Defaults[i], extendedEnv)
+		if isError(value) {
+			return nil, value
+		}
+		extendedEnv.Set(param.Value, value)
+	}
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		extendedEnv.Set(fn.Rest.Value, object.NewArray(rest))
 	}
-	return extendedEnv
+	return extendedEnv, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -277,3 +775,225 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	}
 	return obj
 }
+
+func (e *Evaluator) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for _, pair := range node.Pairs {
+		key := e.Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", key.Type())
+		}
+		value := e.Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := arrayIndex(left, index)
+		if err != nil {
+			return err
+		}
+		return left.Elements[i]
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", index.Type())
+		}
+		value, ok := left.Get(key)
+		if !ok {
+			return object.NewNil()
+		}
+		return value
+	}
+	return object.NewError("index operator not supported: %s", left.Type())
+}
+
+func (e *Evaluator) evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	value := e.Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if target.Resolved && env.AssignAt(target.Depth, target.Slot, target.Value, value) {
+			break
+		}
+		if !env.Assign(target.Value, value) {
+			return object.NewError("undefined identifier %v", target.Value)
+		}
+	case *ast.IndexExpression:
+		left := e.Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := e.Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		if err := evalIndexAssignment(left, index, value); err != nil {
+			return err
+		}
+	case *ast.GetExpression:
+		obj := e.Eval(target.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		if err := SetProperty(obj, target.Name.Value, value); err != nil {
+			return err
+		}
+	default:
+		return object.NewError("invalid assignment target")
+	}
+	return value
+}
+
+func evalIndexAssignment(left, index, value object.Object) *object.Error {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := arrayIndex(left, index)
+		if err != nil {
+			return err
+		}
+		left.Elements[i] = value
+		return nil
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+		return nil
+	}
+	return object.NewError("index assignment not supported: %s", left.Type())
+}
+
+func (e *Evaluator) evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := e.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	var keys []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Hash:
+		// ループ中にキーが追加されても影響しないように、先にキーを取り出しておく
+		keys = iterable.Keys()
+	case *object.Array:
+		// 配列は要素を順に取り出す
+		keys = append(keys, iterable.Elements...)
+	default:
+		return object.NewError("not iterable: %s", iterable.Type())
+	}
+	for _, key := range keys {
+		if err := e.CheckContext(); err != nil {
+			return err
+		}
+		// ループ変数は繰り返しごとに新しいスコープに束縛する
+		loopEnv := object.NewFrame(env, node.Locals)
+		loopEnv.Set(node.Key.Value, key)
+		result := e.evalStatements(node.Body.Statements, loopEnv)
+		if result != nil && (result.Type() == object.RETURN || result.Type() == object.ERROR) {
+			return result
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) evalClassStatement(node *ast.ClassStatement, env *object.Environment) object.Object {
+	class := &object.Class{
+		Name:    node.Name.Value,
+		Methods: make(map[string]object.Method),
+	}
+	methodEnv := env
+	if node.Superclass != nil {
+		superclass := e.Eval(node.Superclass, env)
+		if isError(superclass) {
+			return superclass
+		}
+		sc, ok := superclass.(*object.Class)
+		if !ok {
+			return object.NewError("superclass must be a class: %s", superclass.Type())
+		}
+		class.Superclass = sc
+		// メソッドからは super でスーパークラスを参照できる
+		methodEnv = object.NewEnclosedEnvironment(env)
+		methodEnv.Set("super", sc)
+	}
+	for _, method := range node.Methods {
+		fn := newFunction(method, methodEnv)
+		fn.Name = class.Name + "." + method.Name.Value
+		fn.IsInitializer = method.Name.Value == "init"
+		class.Methods[method.Name.Value] = fn
+	}
+	if env.HasOwn(node.Name.Value) {
+		return redeclarationError(node.Name)
+	}
+	env.Set(node.Name.Value, class)
+	return nil
+}
+
+func evalGetExpression(obj object.Object, name string) object.Object {
+	holder, ok := obj.(object.PropertyHolder)
+	if !ok {
+		return object.NewError("only instances have properties: %s", obj.Type())
+	}
+	value, ok := holder.Get(name)
+	if !ok {
+		return object.NewError("undefined property %s", name)
+	}
+	return value
+}
+
+func evalSuperExpression(node *ast.SuperExpression, env *object.Environment) object.Object {
+	superclass, ok := env.Get("super")
+	if !ok {
+		return object.NewError("'super' used outside of a subclass")
+	}
+	this, ok := env.Get("this")
+	if !ok {
+		return object.NewError("'super' used outside of a method")
+	}
+	method, ok := superclass.(*object.Class).FindMethod(node.Method.Value)
+	if !ok {
+		return object.NewError("undefined property %s", node.Method.Value)
+	}
+	return method.BindMethod(this.(*object.Instance))
+}
+
+func arrayIndex(array *object.Array, index object.Object) (int, *object.Error) {
+	i, ok := index.(*object.Integer)
+	if !ok {
+		return 0, object.NewError("array index must be INTEGER, got %s", index.Type())
+	}
+	if i.Value < 0 || i.Value >= len(array.Elements) {
+		return 0, object.NewError("index out of range: %d (length %d)", i.Value, len(array.Elements))
+	}
+	return i.Value, nil
+}
+
+// 呼び出し中の関数の位置を外側から順に並べ、最後にエラーが起きた位置を加える
+// ホストの Go コードからの呼び出し(行番号 0)はトレースに含めない
+func (e *Evaluator) attachStackTrace(err *object.Error, line int) {
+	err.Line = line
+	caller := "<main>"
+	stack := make([]object.Frame, 0, len(e.frames)+1)
+	for _, f := range e.frames {
+		if f.callLine != 0 {
+			stack = append(stack, object.Frame{Function: caller, Line: f.callLine})
+		}
+		caller = f.function
+	}
+	if line != 0 {
+		stack = append(stack, object.Frame{Function: caller, Line: line})
+	}
+	err.Stack = stack
+}
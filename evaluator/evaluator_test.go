@@ -0,0 +1,920 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/parser"
+	"go-interpreter-practice/resolver"
+	"go-interpreter-practice/scanner"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEval(t *testing.T, e *Evaluator, input string) object.Object {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return e.Eval(program, object.NewEnvironment())
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	testCases := []struct {
+		input    string
+		maxDepth int
+		want     string
+	}{
+		{
+			input:    "func f(n) { return 1 + f(n + 1) }\nf(0)",
+			maxDepth: DefaultMaxCallDepth,
+			want:     "maximum recursion depth exceeded (10000)",
+		},
+		{
+			input:    "func f(n) { if (n == 0) { return 0 } return 1 + f(n - 1) }\nf(100)",
+			maxDepth: 50,
+			want:     "maximum recursion depth exceeded (50)",
+		},
+		{
+			input:    "func f(n) { if (n == 0) { return 0 } return 1 + f(n - 1) }\nf(100)",
+			maxDepth: 200,
+			want:     "100",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			e := NewEvaluator(io.Discard)
+			e.SetMaxCallDepth(tc.maxDepth)
+			result := testEval(t, e, tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+// 末尾再帰は呼び出しの深さの上限に関係なく、100万段でも実行できる
+func TestTailCall(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "return による自己再帰",
+			input: `func count(n, acc) {
+  if (n == 0) { return acc }
+  return count(n - 1, acc + 1)
+}
+count(1000000, 0)`,
+			want: "1000000",
+		},
+		{
+			name:  "最後の式による自己再帰",
+			input: "func loop(n) { if (n == 0) { \"done\" } else { loop(n - 1) } }\nloop(1000000)",
+			want:  "done",
+		},
+		{
+			name: "相互再帰",
+			input: `func isEven(n) { if (n == 0) { return true } return isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false } return isEven(n - 1) }
+isEven(1000001)`,
+			want: "false",
+		},
+		{
+			name:  "末尾位置ではない再帰は上限で止まる",
+			input: "func sum(n) { if (n == 0) { return 0 } return n + sum(n - 1) }\nsum(1000000)",
+			want:  "maximum recursion depth exceeded (10000)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEvaluator(io.Discard)
+			result := testEval(t, e, tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+			if len(e.frames) != 0 {
+				t.Errorf("expected empty call stack, but got %d frames", len(e.frames))
+			}
+		})
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+	forever := "func forever(n) { return forever(n + 1) }\nforever(0)"
+	testCases := []struct {
+		name    string
+		input   string
+		limits  Limits
+		timeout time.Duration
+		want    string
+	}{
+		{
+			name:   "ステップ数の上限",
+			input:  forever,
+			limits: Limits{MaxSteps: 1000},
+			want:   "step limit exceeded (max 1000 steps)",
+		},
+		{
+			name:   "オブジェクト数の上限",
+			input:  "func build(n, acc) { if (n == 0) { return acc } return build(n - 1, [acc]) }\nbuild(1000, [])",
+			limits: Limits{MaxAllocations: 500},
+			want:   "allocation limit exceeded (max 500 objects)",
+		},
+		{
+			name:   "文字列の大きさも数える",
+			input:  "var s = \"x\"\nfor (i in range(30)) { s = s + s }",
+			limits: Limits{MaxAllocations: 1000000},
+			want:   "allocation limit exceeded (max 1000000 objects)",
+		},
+		{
+			name:   "組み込み関数の結果も数える",
+			input:  "var a = range(1000)\nvar b = range(1000)",
+			limits: Limits{MaxAllocations: 1500},
+			want:   "allocation limit exceeded (max 1500 objects)",
+		},
+		{
+			name:   "出力の上限",
+			input:  "for (i in [1, 2, 3, 4]) { print \"hello\" }",
+			limits: Limits{MaxOutputBytes: 15},
+			want:   "output limit exceeded (max 15 bytes)",
+		},
+		{
+			name:    "期限切れ",
+			input:   forever,
+			timeout: 10 * time.Millisecond,
+			want:    "execution cancelled: context deadline exceeded",
+		},
+		{
+			name:   "上限に達しなければ結果を返す",
+			input:  "var total = 0\nfor (i in [1, 2, 3]) { total = total + i }\ntotal",
+			limits: Limits{MaxSteps: 1000, MaxAllocations: 1000, MaxOutputBytes: 10},
+			want:   "6",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			p := parser.NewParser(scanner.NewScanner(tc.input))
+			program, err := p.Parse()
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			result := NewEvaluator(io.Discard).EvalContext(ctx, program, object.NewEnvironment(), tc.limits)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `len("héllo")`, want: "5"},
+		{input: `len([1, 2, 3])`, want: "3"},
+		{input: `len({"a": 1})`, want: "1"},
+		{input: `len(1)`, want: "len: unsupported argument type INTEGER"},
+		{input: `len()`, want: "wrong number of arguments: len expects 1, got 0"},
+		{input: `type(1.5)`, want: "float"},
+		{input: `str(12) + "!"`, want: "12!"},
+		{input: `int("42") + int(3.9)`, want: "45"},
+		{input: `int("x")`, want: `int: cannot convert "x" to integer`},
+		{input: `float(2) / 4`, want: "0.5"},
+		{input: `range(10, 0, -3)`, want: "[10, 7, 4, 1]"},
+		{input: `range(1, 2, 0)`, want: "range: step must not be zero"},
+		{input: `range(0, 9223372036854775807, 9223372036854775807)`, want: "[0]"},
+		{input: `range(9223372036854775806, 9223372036854775807)`, want: "[9223372036854775806]"},
+		{input: `range(5, 0, -9223372036854775807 - 1)`, want: "[5]"},
+		{input: `range(-9223372036854775807 - 1, 9223372036854775807)`, want: "range: too many elements (18446744073709551615, limit 16777216)"},
+		{input: `range(9223372036854775807, -9223372036854775807 - 1, -1)`, want: "range: too many elements (18446744073709551615, limit 16777216)"},
+		{input: `assert(1 == 2, "math is broken")`, want: "assertion failed: math is broken"},
+		{input: `var name = input("name? ")
+println("hi", name)
+name`, want: "onu"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			e := NewEvaluator(io.Discard)
+			e.SetInput(strings.NewReader("onu\n"))
+			result := testEval(t, e, tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestHashes(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `{"name": "x", 1: true}["name"]`, want: "x"},
+		{input: `{1: "int", 1.5: "float", true: "bool", "1": "string"}[1]`, want: "int"},
+		{input: `{1: "int", 1.5: "float", true: "bool", "1": "string"}[true]`, want: "bool"},
+		{input: `{1: "int", 1.5: "float", true: "bool", "1": "string"}["1"]`, want: "string"},
+		{input: `{"a": 1, "a": 2}["a"]`, want: "2"},
+		{input: `{"a": 1}["b"]`, want: "nil"},
+		{input: `var h = {}
+h["a"] = 1
+h["a"] = h["a"] + 1
+h`, want: `{"a": 2}`},
+		{input: `var h = {
+  "a": 1,
+  "b": 2,
+}
+len(h)`, want: "2"},
+		{input: `var keys = ""
+for (k in {"a": 1, "b": 2, "c": 3}) { keys = keys + k }
+len(keys)`, want: "3"},
+		{input: `var sum = 0
+var h = {"a": 1, "b": 2}
+for (k in h) { sum = sum + h[k] }
+sum`, want: "3"},
+		{input: `{[1]: 1}`, want: "unusable as hash key: ARRAY"},
+		{input: `{"a": 1}[[1]]`, want: "unusable as hash key: ARRAY"},
+		{input: `for (x in 1) {}`, want: "not iterable: INTEGER"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestClasses(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		// 継承したメソッドは祖先のクラスまでたどって探す
+		{input: `class A { name() { return "A" } }
+class B < A {}
+class C < B {}
+C().name()`, want: "A"},
+		{input: `class A { name() { return "A" } }
+class B < A { name() { return "B" + super.name() } }
+class C < B { name() { return "C" + super.name() } }
+C().name()`, want: "CBA"},
+		// super はメソッドが定義されたクラスの親から探す
+		{input: `class A { name() { return "A" } }
+class B < A { name() { return "B" } describe() { return super.name() } }
+class C < B { name() { return "C" } }
+C().describe()`, want: "A"},
+		{input: `class A { greet() { return "hi " + this.name() } name() { return "A" } }
+class B < A { name() { return "B" } }
+B().greet()`, want: "hi B"},
+		// フィールド
+		{input: `class Counter {
+  init() { this.n = 0 }
+  inc() { this.n = this.n + 1
+    return this }
+}
+Counter().inc().inc().n`, want: "2"},
+		{input: `class A { name() { return "method" } }
+var a = A()
+a.name = "field"
+a.name`, want: "field"},
+		{input: `class A {}
+var a = A()
+var b = A()
+a.x = 1
+b.x = 2
+a.x + b.x`, want: "3"},
+		// init は常にインスタンスを返す
+		{input: `class A { init() { this.x = 1
+    return nil } }
+var a = A()
+a.init() == a`, want: "true"},
+		{input: `class A { init(x) { this.x = x } }
+var a = A(1)
+a.init(2) == a`, want: "true"},
+		{input: `class A { init(x) { this.x = x } }
+var a = A(1)
+a.init(2)
+a.x`, want: "2"},
+		{input: `class A { init(x) { this.x = x } }
+class B < A {}
+B(5).x`, want: "5"},
+		{input: `class A {}
+A().missing`, want: "undefined property missing"},
+		{input: `class A { name() { return super.name() } }
+A().name()`, want: "'super' used outside of a subclass"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `print 1`, want: "1\n"},
+		{input: `print "a"; print "b"`, want: "a\nb\n"},
+		{input: `print 1 + 2 * 3`, want: "7\n"},
+		{input: `print nil`, want: "nil\n"},
+		{input: `print nil == nil`, want: "true\n"},
+		{input: `print nil == false`, want: "false\n"},
+		{input: `var x = nil
+print x == nil`, want: "true\n"},
+		{input: `func f() { var y = 1 }
+print f() == nil`, want: "true\n"},
+		{input: `print {"a": nil}["a"] == {}["b"]`, want: "true\n"},
+		{input: `if (nil) { print "yes" } else { print "no" }`, want: "no\n"},
+		{input: `print type(nil)`, want: "nil\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			var out strings.Builder
+			result := testEval(t, NewEvaluator(&out), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				t.Fatalf("unexpected error: %v", err.Message)
+			}
+			if out.String() != tc.want {
+				t.Errorf("expected %q, but got %q", tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestCallArguments(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		// 引数の数
+		{input: `func add(a, b) { return a + b }
+add(1)`, want: "wrong number of arguments: add expects 2, got 1 (called at line 2)"},
+		{input: `func add(a, b) { return a + b }
+
+add(1, 2, 3)`, want: "wrong number of arguments: add expects 2, got 3 (called at line 3)"},
+		{input: `var f = func() { return 1 }
+f(1)`, want: "wrong number of arguments: f expects 0, got 1 (called at line 2)"},
+		{input: `[func() { return 1 }][0](1)`, want: "wrong number of arguments: <anonymous> expects 0, got 1 (called at line 1)"},
+		// 省略できる引数
+		{input: `func greet(name, greeting = "hello") { return greeting + " " + name }
+greet("onu")`, want: "hello onu"},
+		{input: `func greet(name, greeting = "hello") { return greeting + " " + name }
+greet("onu", "hi")`, want: "hi onu"},
+		{input: `func f(a, b = a * 2) { return b }
+f(3)`, want: "6"},
+		{input: `var n = 0
+func next(step = n + 1) { n = step
+  return n }
+next()
+next()`, want: "2"},
+		{input: `func f(a, b = 1, c = 2) { return a + b + c }
+f()`, want: "wrong number of arguments: f expects 1 to 3, got 0 (called at line 2)"},
+		{input: `func f(a, b = 1, c = 2) { return a + b + c }
+f(1, 2, 3, 4)`, want: "wrong number of arguments: f expects 1 to 3, got 4 (called at line 2)"},
+		// 残りの引数
+		{input: `func f(a, ...rest) { return rest }
+f(1, 2, 3)`, want: "[2, 3]"},
+		{input: `func f(a, ...rest) { return rest }
+f(1)`, want: "[]"},
+		{input: `func f(a, b = 10, ...rest) { return [a, b, rest] }
+f(1)`, want: "[1, 10, []]"},
+		{input: `func f(a, ...rest) { return rest }
+f()`, want: "wrong number of arguments: f expects at least 1, got 0 (called at line 2)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `func f(a = 1, b) {}`, want: "line 1; parameter b without default follows parameter with default"},
+		{input: `func f(...rest, a) {}`, want: "line 1; rest parameter must be last"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			_, err := parser.NewParser(scanner.NewScanner(tc.input)).Parse()
+			if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+				t.Errorf("expected %v, but got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// 関数宣言は同じブロックの文より先に束縛されるが、var は書かれた順に束縛される
+func TestHoisting(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `var x = f()
+func f() { return 1 }
+x`, want: "1"},
+		{input: `func isEven(n) { if (n == 0) { return true } return isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false } return isEven(n - 1) }
+isEven(7)`, want: "false"},
+		{input: `func f() { return g() }
+var a = f()
+func g() { return 2 }
+a`, want: "2"},
+		{input: `func f() { return later }
+var x = f()
+var later = 1`, want: "undefined identifier later"},
+		{input: `func f() { return later }
+var later = 1
+f()`, want: "1"},
+		{input: `func outer() {
+  var r = inner()
+  func inner() { return "inner" }
+  return r
+}
+outer()`, want: "inner"},
+		{input: `if (true) {
+  var r = g()
+  func g() { return "block" }
+  r
+}`, want: "block"},
+		{input: `if (true) { func g() { return 1 } }
+g()`, want: "undefined identifier g"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+// ブロックの中の var は外側の同じ名前を隠し、ブロックを抜けると外側の値に戻る
+func TestBlockScope(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `var x = 1
+if (true) { var x = 2 }
+x`, want: "1"},
+		{input: `var x = 1
+if (true) { x = 2 }
+x`, want: "2"},
+		{input: `if (true) { var y = 1 }
+y`, want: "undefined identifier y"},
+		{input: `var x = "outer"
+func f() { var x = "inner"
+  return x }
+f() + " " + x`, want: "inner outer"},
+		{input: `var x = 1
+if (true) { var x = x + 1
+  x = x * 10 }
+x`, want: "1"},
+		{input: `var s = 0
+for (i in [1, 2]) { var s = i }
+s`, want: "0"},
+		{input: `func f(a) { if (true) { var a = 2 }
+  return a }
+f(1)`, want: "1"},
+		// 同じスコープでの再宣言
+		{input: `var x = 1
+var x = 2`, want: "x is already declared in this scope (line 2)"},
+		{input: `if (true) {
+  var y = 1
+  var y = 2
+}`, want: "y is already declared in this scope (line 3)"},
+		{input: `func f() {}
+func f() {}`, want: "f is already declared in this scope (line 2)"},
+		// 関数は先に束縛されるので、後から実行される var が再宣言になる
+		{input: `var f = 1
+func f() {}`, want: "f is already declared in this scope (line 1)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func TestTraceback(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `1 + nope`, want: `ERROR: undefined identifier nope
+Traceback (most recent call last):
+  line 1, in <main>`},
+		{input: `func inner() {
+  return 1 + nope
+}
+func outer() {
+  var r = inner()
+  return r
+}
+outer()`, want: `ERROR: undefined identifier nope
+Traceback (most recent call last):
+  line 8, in <main>
+  line 5, in outer
+  line 2, in inner`},
+		// 末尾呼び出しは呼び出し元のフレームを残さない
+		{input: `func inner() { return nope }
+func outer() { return inner() }
+outer()`, want: `ERROR: undefined identifier nope
+Traceback (most recent call last):
+  line 3, in <main>
+  line 1, in inner`},
+		{input: `var apply = func(f) { return f() + 0 }
+apply(func() { return 1 / 0 })`, want: `ERROR: division by zero
+Traceback (most recent call last):
+  line 2, in <main>
+  line 1, in apply
+  line 2, in <anonymous>`},
+		{input: `class A {
+  boom() { return this.missing }
+}
+A().boom()`, want: `ERROR: undefined property missing
+Traceback (most recent call last):
+  line 4, in <main>
+  line 2, in A.boom`},
+		// 深い再帰は先頭と末尾の 10 フレームずつだけ表示する
+		{input: `func down(n) {
+  if (n == 0) { return nope }
+  return down(n - 1) + 0
+}
+down(30)`, want: `ERROR: undefined identifier nope
+Traceback (most recent call last):
+  line 5, in <main>
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  ... 12 more frames ...
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 3, in down
+  line 2, in down`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if _, ok := result.(*object.Error); !ok {
+				t.Fatalf("expected error, but got %v", result)
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected\n%v\nbut got\n%v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+// nil はどこで作られても同じ NilObject になる
+func TestNilIsShared(t *testing.T) {
+	for _, input := range []string{`nil`, `var x = nil
+x`, `func f() { var y = 1 }
+f()`, `{}["missing"]`} {
+		if result := testEval(t, NewEvaluator(io.Discard), input); result != object.NilObject {
+			t.Errorf("%s: expected NilObject, but got %#v", input, result)
+		}
+	}
+}
+
+// 整数の / は負の無限大に向かって丸め(-7 / 2 == -4)、% の符号は割る数に合わせる
+// 浮動小数点数も 0 で割るとエラーになる
+func TestArithmetic(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: `7 / 2`, want: "3"},
+		{input: `-7 / 2`, want: "-4"},
+		{input: `7 / -2`, want: "-4"},
+		{input: `-7 / -2`, want: "3"},
+		{input: `7 % 3`, want: "1"},
+		{input: `-7 % 3`, want: "2"},
+		{input: `7 % -3`, want: "-2"},
+		{input: `-7 % -3`, want: "-1"},
+		{input: `-7.5 / 2`, want: "-3.75"},
+		{input: `-7.5 % 2`, want: "0.5"},
+		{input: `7.5 % -2`, want: "-0.5"},
+		{input: `9223372036854775807 + 1`, want: "integer overflow: 9223372036854775807 + 1"},
+		{input: `-9223372036854775807 - 2`, want: "integer overflow: -9223372036854775807 - 2"},
+		{input: `9223372036854775807 * 2`, want: "integer overflow: 9223372036854775807 * 2"},
+		{input: `var min = -9223372036854775807 - 1
+min * -1`, want: "integer overflow: -9223372036854775808 * -1"},
+		{input: `var min = -9223372036854775807 - 1
+min / -1`, want: "integer overflow: -9223372036854775808 / -1"},
+		{input: `var min = -9223372036854775807 - 1
+min % -1`, want: "0"},
+		{input: `1 / 0`, want: "division by zero"},
+		{input: `1 % 0`, want: "modulo by zero"},
+		{input: `1.5 / 0`, want: "division by zero"},
+		{input: `1 / 0.0`, want: "division by zero"},
+		{input: `1.5 % 0`, want: "modulo by zero"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			result := testEval(t, NewEvaluator(io.Discard), tc.input)
+			if err, ok := result.(*object.Error); ok {
+				if err.Message != tc.want {
+					t.Errorf("expected %v, but got %v", tc.want, err.Message)
+				}
+				return
+			}
+			if result.String() != tc.want {
+				t.Errorf("expected %v, but got %v", tc.want, result.String())
+			}
+		})
+	}
+}
+
+func resolvedProgram(t testing.TB, e *Evaluator, input string) *ast.Program {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := resolver.NewResolver(e.HasBuiltin).Resolve(program); err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	return program
+}
+
+// resolver で変数の位置を求めたプログラムも、名前で探すときと同じ結果になる
+func TestResolvedEnvironments(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ブロックでの隠蔽", "var x = 1\nif (true) { var x = 2\nx = 3 }\nx", "1"},
+		{"外側への代入", "var x = 1\nif (true) { x = x + 1 }\nx", "2"},
+		{"クロージャのカウンタ", `func counter() {
+  var n = 0
+  return func() { n = n + 1
+    return n }
+}
+var c = counter()
+c()
+c()
+c()`, "3"},
+		{"後で宣言されるローカル変数", `func outer() {
+  func inner() { return y }
+  var y = 10
+  return inner()
+}
+outer()`, "10"},
+		{"宣言前は外側の変数が見える", `var y = 1
+func outer() {
+  var before = y
+  if (true) { var z = before }
+  var y = 2
+  return before + y
+}
+outer()`, "3"},
+		{"デフォルト値と可変長引数", "func f(a, b = a * 2, ...rest) { return a + b + len(rest) }\nf(1) + f(1, 1, 5, 6)", "7"},
+		{"for のループ変数", "var sum = 0\nfor (i in [1, 2, 3]) { var d = i * 2\nsum = sum + d }\nsum", "12"},
+		{"名前付き関数式の再帰", "var f = func fact(n) { if (n == 0) { return 1 } return n * fact(n - 1) }\nf(5)", "120"},
+		{"クラスと super", `class A { init(x) { this.x = x } get() { return this.x } }
+class B < A { get() { return super.get() * 10 } }
+B(4).get()`, "40"},
+		{"組み込み関数", "len([1, 2])", "2"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unresolved := testEval(t, NewEvaluator(io.Discard), tc.input)
+			e := NewEvaluator(io.Discard)
+			resolved := e.Eval(resolvedProgram(t, e, tc.input), object.NewEnvironment())
+			if unresolved.String() != tc.want || resolved.String() != tc.want {
+				t.Errorf("unresolved = %s, resolved = %s, want %s", unresolved, resolved, tc.want)
+			}
+		})
+	}
+}
+
+const benchmarkFib = `func fib(n) {
+  if (n < 2) { return n }
+  var a = fib(n - 1)
+  var b = fib(n - 2)
+  return a + b
+}
+fib(20)`
+
+func BenchmarkFibByName(b *testing.B) {
+	p := parser.NewParser(scanner.NewScanner(benchmarkFib))
+	program, err := p.Parse()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewEvaluator(io.Discard).Eval(program, object.NewEnvironment())
+	}
+}
+
+func BenchmarkFibResolved(b *testing.B) {
+	e := NewEvaluator(io.Discard)
+	program := resolvedProgram(b, e, benchmarkFib)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Eval(program, object.NewEnvironment())
+	}
+}
+
+// recorder はフックが呼ばれた順に記録する
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) OnStatement(node ast.Statement, env *object.Environment, result object.Object) {
+	r.events = append(r.events, fmt.Sprintf("statement %d %v", node.Line(), result))
+}
+
+func (r *recorder) OnCall(fn *object.Function, env *object.Environment) {
+	r.events = append(r.events, fmt.Sprintf("call %s %v", fn.Name, env.Names()))
+}
+
+func (r *recorder) OnReturn(fn *object.Function, env *object.Environment, result object.Object) {
+	r.events = append(r.events, fmt.Sprintf("return %s %v", fn.Name, result))
+}
+
+func (r *recorder) OnError(node ast.Node, env *object.Environment, err *object.Error) {
+	r.events = append(r.events, fmt.Sprintf("error %d %s", node.Line(), err.Message))
+}
+
+func TestHooks(t *testing.T) {
+	input := `func add(a, b) {
+  return a + b
+}
+func twice(x) { return add(x, x) }
+var r = twice(2)
+if (r > 0) { r + nil }`
+	e := NewEvaluator(io.Discard)
+	hooks := &recorder{}
+	e.SetHooks(hooks)
+	testEval(t, e, input)
+
+	expected := []string{
+		"statement 1 <nil>",
+		"statement 4 <nil>",
+		"call twice [x]",
+		// 末尾呼び出しは twice のフレームを add に置き換える
+		"statement 4 <nil>",
+		"return twice <nil>",
+		"call add [a b]",
+		"statement 2 4",
+		"return add 4",
+		"statement 5 <nil>",
+		// エラーは起きた式で一度だけ知らせ、それを含む文にはエラーの値を渡す
+		"error 6 unknown operator: INTEGER + NIL",
+		"statement 6 ERROR: unknown operator: INTEGER + NIL",
+		"statement 6 ERROR: unknown operator: INTEGER + NIL",
+	}
+	got := make([]string, len(hooks.events))
+	for i, event := range hooks.events {
+		got[i], _, _ = strings.Cut(event, "\n")
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestTracer(t *testing.T) {
+	input := `func square(n) {
+  var result = n * n
+  return result
+}
+var x = square(3)
+print x
+if (x > 5) {
+  x
+}`
+	var out, trace strings.Builder
+	e := NewEvaluator(&out)
+	e.SetHooks(NewTracer(&trace))
+	testEval(t, e, input)
+
+	// 文は評価し終えた順に並び、関数の中の文は呼び出しの深さだけ字下げする
+	expected := `1: func square(n) { ...
+  2: var result = (n * n) => 9
+  3: return result => 9
+5: var x = square(3) => 9
+6: print x
+8: x => 9
+7: if ((x > 5)) { ... => 9
+`
+	if trace.String() != expected {
+		t.Errorf("got trace:\n%s\nwant:\n%s", trace.String(), expected)
+	}
+	if out.String() != "9\n" {
+		t.Errorf("got output %q", out.String())
+	}
+}
@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+)
+
+// Hooks は評価の様子を外から観察するためのフック
+// プロファイラ、カバレッジの計測、実行の記録などに使う
+// 実行を止めたいときは Debugger を使う
+type Hooks interface {
+	// OnStatement は文を評価した後で、文を評価した環境と文の値を渡して呼ばれる(ブロック自体は除く)
+	// return 文の値は返す値で、値を持たない文なら nil になる
+	// 末尾呼び出しを返す return 文は、呼び出した関数が終わるより前に呼ばれるので nil になる
+	OnStatement(node ast.Statement, env *object.Environment, result object.Object)
+	// OnCall は関数の本体を実行する直前に、引数を束縛した環境を渡して呼ばれる
+	OnCall(fn *object.Function, env *object.Environment)
+	// OnReturn は関数の本体を実行し終えたときに、戻り値(エラーのこともある)を渡して呼ばれる
+	// 末尾呼び出しで別の関数に置き換えられたときは result が nil になる
+	OnReturn(fn *object.Function, env *object.Environment, result object.Object)
+	// OnError は実行時エラーが起きたノードで、エラーごとに一度だけ呼ばれる
+	// err にはスタックトレースが付いている
+	OnError(node ast.Node, env *object.Environment, err *object.Error)
+}
+
+// SetHooks は評価に使うフックを設定する
+// nil なら何も呼ばない
+func (e *Evaluator) SetHooks(h Hooks) {
+	e.hooks = h
+}
+
+// NopHooks は何もしない Hooks
+// 埋め込んで必要なメソッドだけを実装するのに使う
+type NopHooks struct{}
+
+func (NopHooks) OnStatement(node ast.Statement, env *object.Environment, result object.Object) {}
+
+func (NopHooks) OnCall(fn *object.Function, env *object.Environment) {}
+
+func (NopHooks) OnReturn(fn *object.Function, env *object.Environment, result object.Object) {}
+
+func (NopHooks) OnError(node ast.Node, env *object.Environment, err *object.Error) {}
+
+// hookedStatement はフックを呼ぶ文かどうかを返す
+// ブロックは中の文ごとに呼ぶので除く
+func hookedStatement(node ast.Node) (ast.Statement, bool) {
+	statement, ok := node.(ast.Statement)
+	if !ok {
+		return nil, false
+	}
+	if _, isBlock := statement.(*ast.BlockStatement); isBlock {
+		return nil, false
+	}
+	return statement, true
+}
+
+// statementValue は評価器の内部の値を取り除いた文の値を返す
+func statementValue(result object.Object) object.Object {
+	result = unwrapReturnValue(result)
+	if _, ok := result.(*tailCall); ok {
+		return nil
+	}
+	return result
+}
+
+// Tracer は評価した文を一行ずつ、行番号と値を付けて書き出す Hooks
+// 関数の中の文は呼び出しの深さだけ字下げする
+// 文は評価し終えたときに書くので、if や for などの本体を持つ文は本体の文の後に出る
+type Tracer struct {
+	NopHooks
+	out   io.Writer
+	depth int
+}
+
+// NewTracer は out に書き出す Tracer を作る
+func NewTracer(out io.Writer) *Tracer {
+	return &Tracer{out: out}
+}
+
+// OnStatement は文の最初の行と値を書き出す
+// var 文は宣言した変数の値を書く
+func (t *Tracer) OnStatement(node ast.Statement, env *object.Environment, result object.Object) {
+	source, _, multiline := strings.Cut(node.String(), "\n")
+	if multiline {
+		source += " ..."
+	}
+	if declaration, ok := node.(*ast.VarStatement); ok && result == nil {
+		result, _ = env.Get(declaration.Name.Value)
+	}
+	value := ""
+	if result != nil {
+		value = " => " + firstLine(result)
+	}
+	fmt.Fprintf(t.out, "%s%d: %s%s\n", strings.Repeat("  ", t.depth), node.Line(), source, value)
+}
+
+// firstLine は値を一行で表す(関数は本体を、エラーはスタックトレースを省く)
+func firstLine(value object.Object) string {
+	if fn, ok := value.(*object.Function); ok {
+		return "func " + fn.DisplayName()
+	}
+	line, _, _ := strings.Cut(value.String(), "\n")
+	return line
+}
+
+func (t *Tracer) OnCall(fn *object.Function, env *object.Environment) {
+	t.depth++
+}
+
+func (t *Tracer) OnReturn(fn *object.Function, env *object.Environment, result object.Object) {
+	t.depth--
+}
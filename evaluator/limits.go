@@ -0,0 +1,189 @@
+package evaluator
+
+import (
+	"context"
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/object"
+	"os"
+)
+
+// Limits は 1 回の実行で使える資源の上限
+// 0 の項目は無制限になる
+type Limits struct {
+	MaxSteps       int // 評価するノードの数
+	MaxAllocations int // 生成するオブジェクトの数(値、関数、インスタンス、呼び出しごとのスコープ)。文字列はバイト数、配列とハッシュは要素数を加えて数える
+	MaxOutputBytes int // print で出力するバイト数
+}
+
+// どの上限に達したかを表すエラーメッセージ
+const (
+	StepLimitExceeded       = "step limit exceeded"
+	AllocationLimitExceeded = "allocation limit exceeded"
+	OutputLimitExceeded     = "output limit exceeded"
+	ExecutionCancelled      = "execution cancelled"
+)
+
+// ctx のキャンセルを確認する間隔(ステップ数)
+// ループと関数呼び出しでは毎回確認する
+const contextCheckInterval = 1024
+
+// usage は実行中に使った資源の量
+type usage struct {
+	steps       int
+	allocations int
+	outputBytes int
+}
+
+// EvalContext は標準出力に出力する Evaluator で、キャンセルと上限を確認しながら node を評価する
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) object.Object {
+	return NewEvaluator(os.Stdout).EvalContext(ctx, node, env, limits)
+}
+
+// EvalContext は ctx がキャンセルされるか期限を過ぎるか、limits のどれかに達すると評価を止めてエラーを返す
+func (e *Evaluator) EvalContext(ctx context.Context, node ast.Node, env *object.Environment, limits Limits) object.Object {
+	defer e.WithContext(ctx, limits)()
+	return e.Eval(node, env)
+}
+
+// CallContext はホストの Go コードからスクリプトの関数や組み込み関数を呼び出す
+// EvalContext と同じようにキャンセルと上限を確認する
+func (e *Evaluator) CallContext(ctx context.Context, fn object.Object, args []object.Object, limits Limits) object.Object {
+	defer e.WithContext(ctx, limits)()
+	result := e.applyFunction(fn, args, 0)
+	if err, ok := result.(*object.Error); ok && err.Stack == nil {
+		e.attachStackTrace(err, 0)
+	}
+	return result
+}
+
+// WithContext は実行中の ctx と上限を設定し、元に戻す関数を返す
+// vm パッケージも組み込み関数と上限をこの Evaluator と共有するために使う
+// 実行中にホストの関数から入れ子で呼ばれたときは、使った量を外側の実行と共有し、
+// 外側の ctx のキャンセルと上限も引き続き守る
+func (e *Evaluator) WithContext(ctx context.Context, limits Limits) func() {
+	prevCtx, prevLimits := e.ctx, e.limits
+	if e.running == 0 {
+		e.ctx = ctx
+		e.limits = limits
+		e.usage = usage{}
+		e.running++
+		return func() {
+			e.running--
+			e.ctx, e.limits = prevCtx, prevLimits
+		}
+	}
+
+	nested, cancel := context.WithCancel(ctx)
+	stop := func() bool { return false }
+	if prevCtx != nil {
+		stop = context.AfterFunc(prevCtx, cancel)
+	}
+	e.ctx = nested
+	e.limits = prevLimits.min(limits)
+	e.running++
+	return func() {
+		e.running--
+		stop()
+		cancel()
+		e.ctx, e.limits = prevCtx, prevLimits
+	}
+}
+
+// min は項目ごとに厳しい方の上限を返す
+func (l Limits) min(other Limits) Limits {
+	return Limits{
+		MaxSteps:       minLimit(l.MaxSteps, other.MaxSteps),
+		MaxAllocations: minLimit(l.MaxAllocations, other.MaxAllocations),
+		MaxOutputBytes: minLimit(l.MaxOutputBytes, other.MaxOutputBytes),
+	}
+}
+
+// 0 は無制限なので、0 でない方を優先する
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Step は評価するノード(vm では命令)を数え、上限を超えたらエラーを返す
+func (e *Evaluator) Step() *object.Error {
+	e.usage.steps++
+	if e.limits.MaxSteps > 0 && e.usage.steps > e.limits.MaxSteps {
+		return object.NewError("%s (max %d steps)", StepLimitExceeded, e.limits.MaxSteps)
+	}
+	if e.usage.steps%contextCheckInterval == 0 {
+		return e.CheckContext()
+	}
+	return nil
+}
+
+// CheckContext は ctx がキャンセルされていたらエラーを返す
+func (e *Evaluator) CheckContext() *object.Error {
+	if e.ctx == nil {
+		return nil
+	}
+	if err := e.ctx.Err(); err != nil {
+		return object.NewError("%s: %v", ExecutionCancelled, err)
+	}
+	return nil
+}
+
+// Allocate は n 個のオブジェクトの生成を数え、上限を超えたらエラーを返す
+func (e *Evaluator) Allocate(n int) *object.Error {
+	e.usage.allocations += n
+	if e.limits.MaxAllocations > 0 && e.usage.allocations > e.limits.MaxAllocations {
+		return object.NewError("%s (max %d objects)", AllocationLimitExceeded, e.limits.MaxAllocations)
+	}
+	return nil
+}
+
+// AllocateObject は obj の大きさの分の生成を数え、上限を超えたらエラーを返す
+// vm パッケージも演算や組み込み関数の結果を同じ基準で数えるために使う
+func (e *Evaluator) AllocateObject(obj object.Object) *object.Error {
+	return e.Allocate(AllocationSize(obj))
+}
+
+// checkAllocation は n 個の生成が上限に収まるかを、数えずに確かめる
+// 大きな値を作る前に確かめ、作った結果は呼び出し元で AllocateObject する
+func (e *Evaluator) checkAllocation(n int) *object.Error {
+	if e.limits.MaxAllocations > 0 && e.usage.allocations+n > e.limits.MaxAllocations {
+		return object.NewError("%s (max %d objects)", AllocationLimitExceeded, e.limits.MaxAllocations)
+	}
+	return nil
+}
+
+// AllocationSize は obj を上限に対して何個のオブジェクトとして数えるかを返す
+// 文字列を繰り返しつなげるような、オブジェクトの数は少なくても大きな値を作るスクリプトも止められるようにする
+func AllocationSize(obj object.Object) int {
+	switch obj := obj.(type) {
+	case *object.String:
+		return 1 + len(obj.Value)
+	case *object.Array:
+		return 1 + len(obj.Elements)
+	case *object.Hash:
+		return 1 + len(obj.Keys())
+	}
+	return 1
+}
+
+// WriteOutput は s を出力先に書き、出力の上限を超えたらエラーを返す
+func (e *Evaluator) WriteOutput(s string) *object.Error {
+	e.usage.outputBytes += len(s)
+	if e.limits.MaxOutputBytes > 0 && e.usage.outputBytes > e.limits.MaxOutputBytes {
+		return object.NewError("%s (max %d bytes)", OutputLimitExceeded, e.limits.MaxOutputBytes)
+	}
+	e.out.Write([]byte(s))
+	return nil
+}
+
+// 評価のたびに新しいオブジェクトを作るノードかどうか
+func allocates(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean,
+		*ast.PrefixExpression, *ast.InfixExpression,
+		*ast.ArrayLiteral, *ast.HashLiteral, *ast.FunctionExpression:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package evaluator
+
+import "go-interpreter-practice/object"
+
+// 以下は vm パッケージと共有する演算
+// バックエンドによって結果やエラーメッセージが変わらないように、評価器と同じ実装を使う
+
+// InfixOperation は left operator right を計算する
+func InfixOperation(operator string, left, right object.Object) object.Object {
+	return evalInfixExpression(operator, left, right)
+}
+
+// PrefixOperation は operator right を計算する
+func PrefixOperation(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+// IndexOperation は left[index] を返す
+func IndexOperation(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// IndexAssignment は left[index] に value を代入する
+func IndexAssignment(left, index, value object.Object) *object.Error {
+	return evalIndexAssignment(left, index, value)
+}
+
+// GetProperty は obj.name を返す
+func GetProperty(obj object.Object, name string) object.Object {
+	return evalGetExpression(obj, name)
+}
+
+// SetProperty は obj.name に value を代入する
+func SetProperty(obj object.Object, name string, value object.Object) *object.Error {
+	holder, ok := obj.(object.PropertyHolder)
+	if !ok {
+		return object.NewError("only instances have fields: %s", obj.Type())
+	}
+	return holder.Set(name, value)
+}
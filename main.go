@@ -2,68 +2,295 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
+	"go-interpreter-practice/bind"
+	"go-interpreter-practice/dap"
+	"go-interpreter-practice/debugger"
 	"go-interpreter-practice/evaluator"
 	"go-interpreter-practice/object"
-	"go-interpreter-practice/parser"
-	"go-interpreter-practice/scanner"
+	"go-interpreter-practice/onu"
+	"go-interpreter-practice/stdlib"
 )
 
+// 終了コードは sysexits.h に合わせる
+const (
+	exitCompileError = 65
+	exitRuntimeError = 70
+)
+
+// 使い方:
+//
+//	onu [-backend eval|vm] [-O] [-ast] [-trace] [file]
+//	onu compile [-O] [-o file.onuc] file.onu
+//	onu debug file.onu
+//	onu dap [-listen addr]
+//	onu bind [-o file] [-pkg name] importpath
+//
+// file が .onuc ならコンパイル済みのプログラムを vm で実行する
+// -ast を付けると実行せずに構文木(-O なら最適化した後のもの)を表示する
+// -trace を付けると評価した文を行番号と値とともに標準エラー出力に書く(eval でだけ使える)
+// 文は評価し終えたときに書くので、if や for などの文は本体の文の後に出る
 func main() {
-	execWithFile("test.onu")
-	// args := os.Args
-	// if len(args) == 2 {
-	// 	filePath := args[1]
-	// 	execWithFile(filePath)
-	// } else {
-	// 	callREPL()
-	// }
+	backendName := flag.String("backend", "eval", "how to run scripts: eval (tree-walking evaluator) or vm (bytecode VM)")
+	optimize := flag.Bool("O", false, "optimize the program before running it")
+	printAST := flag.Bool("ast", false, "print the syntax tree instead of running the file")
+	trace := flag.Bool("trace", false, "print each statement with its line and value to stderr after it finishes, so if and for lines follow their bodies (eval backend only)")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) >= 1 && args[0] == "bind" {
+		os.Exit(runBind(args[1:]))
+	}
+	if len(args) >= 1 && args[0] == "compile" {
+		os.Exit(runCompile(args[1:]))
+	}
+	if len(args) >= 1 && args[0] == "debug" {
+		os.Exit(runDebug(args[1:]))
+	}
+	if len(args) >= 1 && args[0] == "dap" {
+		os.Exit(runDAP(args[1:]))
+	}
+	backend, err := onu.ParseBackend(*backendName)
+	if err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		os.Exit(2)
+	}
+	if *trace && backend != onu.TreeWalker {
+		io.WriteString(os.Stderr, "-trace requires the eval backend\n")
+		os.Exit(2)
+	}
+	options := options{backend: backend, optimize: *optimize, trace: *trace}
+	switch {
+	case len(args) == 1 && *printAST:
+		os.Exit(dumpAST(args[0], options))
+	case len(args) == 1:
+		os.Exit(execWithFile(args[0], options))
+	default:
+		callREPL(options)
+	}
+}
+
+// options はコマンドラインで指定した実行方法
+type options struct {
+	backend  onu.Backend
+	optimize bool
+	trace    bool
 }
 
-func execWithFile(filePath string) {
-	f, err := os.Open(filePath)
+func execWithFile(filePath string, options options) int {
+	if filepath.Ext(filePath) == onu.CompiledExt {
+		if options.trace {
+			io.WriteString(os.Stderr, "-trace cannot be used with compiled files\n")
+			return 2
+		}
+		return execCompiled(filePath)
+	}
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		io.WriteString(os.Stdout, err.Error())
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
 	}
-	defer f.Close()
-	var data = make([]byte, 1024)
-	n, err := f.Read(data)
+	interpreter := newInterpreter(options)
+	evaluated, err := interpreter.Run(context.Background(), filePath, string(data))
 	if err != nil {
-		io.WriteString(os.Stdout, err.Error())
+		return reportError(err)
 	}
-	s := scanner.NewScanner(string(data[:n]))
-	parser := parser.NewParser(s)
-	program, err := parser.Parse()
+	printResult(evaluated)
+	return 0
+}
+
+// execCompiled はコンパイル済みファイルを vm で実行する
+func execCompiled(filePath string) int {
+	interpreter := newInterpreter(options{backend: onu.BytecodeVM})
+	bytecode, source, err := interpreter.LoadCompiled(filePath)
 	if err != nil {
-		io.WriteString(os.Stdout, err.Error())
-		io.WriteString(os.Stdout, "\n")
-		return
+		return reportLoadError(err)
+	}
+	evaluated, err := interpreter.RunBytecode(context.Background(), source, bytecode)
+	if err != nil {
+		return reportError(err)
+	}
+	printResult(evaluated)
+	return 0
+}
+
+// dumpAST はファイルを構文解析して構文木を表示する
+func dumpAST(filePath string, options options) int {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
+	}
+	program, err := newInterpreter(options).Compile(filePath, string(data))
+	if err != nil {
+		return reportError(err)
+	}
+	io.WriteString(os.Stdout, program.String()+"\n")
+	return 0
+}
+
+func newInterpreter(options options) *onu.Interpreter {
+	interpreter := onu.New()
+	interpreter.SetBackend(options.backend)
+	interpreter.SetOptimize(options.optimize)
+	if options.trace {
+		interpreter.SetHooks(evaluator.NewTracer(os.Stderr))
+	}
+	stdlib.Register(interpreter)
+	return interpreter
+}
+
+// runBind は Go のパッケージのラッパーを生成する
+// 使い方: onu bind [-o file] [-pkg name] importpath
+func runBind(args []string) int {
+	flags := flag.NewFlagSet("bind", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: stdout)")
+	pkgName := flags.String("pkg", "stdlib", "package name of the generated code")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		io.WriteString(os.Stderr, "usage: onu bind [-o file] [-pkg name] importpath\n")
+		return 2
 	}
+	src, err := bind.Generate(flags.Arg(0), *pkgName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bind %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+		return 0
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
+	}
+	return 0
+}
+
+// runCompile はスクリプトをコンパイルしてコンパイル済みファイルに書き出す
+// 使い方: onu compile [-O] [-o file.onuc] file.onu
+func runCompile(args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: the input with the .onuc extension)")
+	optimize := flags.Bool("O", false, "optimize the program before compiling it")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		io.WriteString(os.Stderr, "usage: onu compile [-O] [-o file.onuc] file.onu\n")
+		return 2
+	}
+	path := flags.Arg(0)
+	out := *output
+	if out == "" {
+		out = strings.TrimSuffix(path, filepath.Ext(path)) + onu.CompiledExt
+	}
+	// 実行するときと同じ組み込み関数とモジュールで名前を検査する
+	if err := newInterpreter(options{backend: onu.BytecodeVM, optimize: *optimize}).CompileFile(path, out); err != nil {
+		return reportLoadError(err)
+	}
+	return 0
+}
 
-	if len(parser.GetErrors()) > 0 {
-		for _, e := range parser.GetErrors() {
-			io.WriteString(os.Stdout, e.Error())
-			io.WriteString(os.Stdout, "\n")
+// runDebug はスクリプトを最初の文で止めてから、標準入力のコマンドでデバッガを操作する
+// 使い方: onu debug file.onu
+func runDebug(args []string) int {
+	if len(args) != 1 {
+		io.WriteString(os.Stderr, "usage: onu debug file.onu\n")
+		return 2
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
+	}
+	console := debugger.NewConsole(os.Stdin, os.Stdout, string(data))
+	d := debugger.New(newInterpreter(options{}), console, true)
+	evaluated, err := d.Run(context.Background(), args[0], string(data))
+	if d.Terminated() {
+		return 0
+	}
+	if err != nil {
+		return reportError(err)
+	}
+	printResult(evaluated)
+	return 0
+}
+
+// runDAP は Debug Adapter Protocol のサーバーを動かす
+// -listen を省くと標準入出力で一つのクライアントとやりとりする
+// -listen :4711 のようにホストを省くとループバックだけで待つ
+// 使い方: onu dap [-listen addr]
+func runDAP(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	listen := flags.String("listen", "", "accept clients on this TCP address, loopback if the host is omitted (default: use stdin and stdout)")
+	flags.Parse(args)
+	newDebuggee := func() *onu.Interpreter { return newInterpreter(options{}) }
+	if *listen == "" {
+		if err := dap.NewServer(os.Stdin, os.Stdout, newDebuggee).Serve(); err != nil {
+			io.WriteString(os.Stderr, err.Error()+"\n")
+			return 1
 		}
-		return
+		return 0
+	}
+	listener, err := dap.Listen(*listen)
+	if err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
 	}
-	env := object.NewEnvironment()
-	evaluated := evaluator.Eval(program, env)
-	if evaluated != nil {
+	fmt.Fprintf(os.Stderr, "listening on %s\n", listener.Addr())
+	if !dap.IsLoopback(listener.Addr()) {
+		io.WriteString(os.Stderr, "warning: clients are not authenticated; anyone who can connect can read and run files as this user\n")
+	}
+	if err := dap.Serve(listener, newDebuggee); err != nil {
+		io.WriteString(os.Stderr, err.Error()+"\n")
+		return 1
+	}
+	return 0
+}
+
+// reportError はエラーを標準エラー出力に書き、終了コードを返す
+func reportError(err error) int {
+	io.WriteString(os.Stderr, err.Error()+"\n")
+	var compileErr *onu.CompileError
+	if errors.As(err, &compileErr) {
+		return exitCompileError
+	}
+	return exitRuntimeError
+}
+
+// reportLoadError はファイルの読み書きのエラーを報告する
+// スクリプトのエラー以外は、ファイルが開けないときと同じく 1 を返す
+func reportLoadError(err error) int {
+	var compileErr *onu.CompileError
+	if errors.As(err, &compileErr) {
+		return reportError(err)
+	}
+	io.WriteString(os.Stderr, err.Error()+"\n")
+	return 1
+}
+
+func printResult(evaluated object.Object) {
+	if evaluated != nil && evaluated != object.NilObject {
 		io.WriteString(os.Stdout, evaluated.String())
 		io.WriteString(os.Stdout, "\n")
 	}
 }
 
-func callREPL() {
+func callREPL(options options) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// 対話型のRPELループを開始
+	// 一つの Interpreter を使い回すので、前の行で宣言した変数を使える
+	interpreter := newInterpreter(options)
 	reader := bufio.NewReader(os.Stdin)
 	done := make(chan struct{})
 	go func() {
@@ -76,24 +303,18 @@ func callREPL() {
 			}
 
 			// 入力が "exit" だったらループを終了
-			if input == "exit" {
+			if input == "exit\n" {
 				io.WriteString(os.Stdout, "RPELを終了します。\n")
 				done <- struct{}{}
 				return
 			}
 
-			s := scanner.NewScanner(input)
-			parser := parser.NewParser(s)
-			program, err := parser.Parse()
+			evaluated, err := interpreter.Run(context.Background(), "<stdin>", input)
 			if err != nil {
-				io.WriteString(os.Stdout, err.Error())
-			}
-			env := object.NewEnvironment()
-			evaluated := evaluator.Eval(program, env)
-			if evaluated != nil {
-				io.WriteString(os.Stdout, evaluated.String())
-				io.WriteString(os.Stdout, "\n")
+				reportError(err)
+				continue
 			}
+			printResult(evaluated)
 		}
 	}()
 
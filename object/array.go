@@ -0,0 +1,43 @@
+package object
+
+import "bytes"
+
+type Array struct {
+	Elements []Object
+}
+
+func NewArray(elements []Object) *Array {
+	return &Array{Elements: elements}
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY
+}
+
+func (a *Array) String() string {
+	return a.inspect(make(map[Object]bool))
+}
+
+// 自分自身を含む配列は [...] として表示する
+func (a *Array) inspect(visiting map[Object]bool) string {
+	if visiting[a] {
+		return "[...]"
+	}
+	visiting[a] = true
+	defer delete(visiting, a)
+
+	var out bytes.Buffer
+	out.WriteString("[")
+	for i, element := range a.Elements {
+		if i != 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(inspect(element, visiting))
+	}
+	out.WriteString("]")
+	return out.String()
+}
+
+func (a *Array) IsTruthy() bool {
+	return len(a.Elements) != 0
+}
@@ -0,0 +1,21 @@
+package object
+
+type BuiltinFunction func(args ...Object) Object
+
+// Builtin は Go で実装された関数
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BUILTIN
+}
+
+func (b *Builtin) String() string {
+	return "builtin " + b.Name
+}
+
+func (b *Builtin) IsTruthy() bool {
+	return true
+}
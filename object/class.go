@@ -0,0 +1,83 @@
+package object
+
+type Class struct {
+	Name       string
+	Superclass *Class
+	Methods    map[string]Method
+}
+
+// Method はクラスのメソッドになる関数
+// 評価器の Function と vm のクロージャが実装する
+type Method interface {
+	Object
+	// BindMethod は this を instance に束縛した関数を返す
+	BindMethod(instance *Instance) Object
+}
+
+func (c *Class) Type() ObjectType {
+	return CLASS
+}
+
+func (c *Class) String() string {
+	return c.Name
+}
+
+func (c *Class) IsTruthy() bool {
+	return true
+}
+
+// FindMethod はスーパークラスを遡ってメソッドを探す
+func (c *Class) FindMethod(name string) (Method, bool) {
+	if method, ok := c.Methods[name]; ok {
+		return method, true
+	}
+	if c.Superclass != nil {
+		return c.Superclass.FindMethod(name)
+	}
+	return nil, false
+}
+
+// PropertyHolder は obj.name でプロパティを読み書きできるオブジェクト
+// Instance のほか、ホストの Go の値を包んだオブジェクトが実装する
+type PropertyHolder interface {
+	Object
+	Get(name string) (Object, bool)
+	Set(name string, value Object) *Error
+}
+
+type Instance struct {
+	Class  *Class
+	Fields map[string]Object
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, Fields: make(map[string]Object)}
+}
+
+func (i *Instance) Type() ObjectType {
+	return INSTANCE
+}
+
+func (i *Instance) String() string {
+	return i.Class.Name + " instance"
+}
+
+func (i *Instance) IsTruthy() bool {
+	return true
+}
+
+// Get はフィールドを優先して探し、なければ this を束縛したメソッドを返す
+func (i *Instance) Get(name string) (Object, bool) {
+	if value, ok := i.Fields[name]; ok {
+		return value, true
+	}
+	if method, ok := i.Class.FindMethod(name); ok {
+		return method.BindMethod(i), true
+	}
+	return nil, false
+}
+
+func (i *Instance) Set(name string, value Object) *Error {
+	i.Fields[name] = value
+	return nil
+}
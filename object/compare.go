@@ -0,0 +1,140 @@
+package object
+
+import "strings"
+
+// Comparable は大小比較できるオブジェクトが実装する
+// 比較できない相手の場合は ok に false を返す
+type Comparable interface {
+	Object
+	Compare(other Object) (result int, ok bool)
+}
+
+func (i *Integer) Compare(other Object) (int, bool) {
+	switch other := other.(type) {
+	case *Integer:
+		return compareInts(i.Value, other.Value), true
+	case *Float:
+		return compareFloats(float64(i.Value), other.Value), true
+	}
+	return 0, false
+}
+
+func (f *Float) Compare(other Object) (int, bool) {
+	switch other := other.(type) {
+	case *Integer:
+		return compareFloats(f.Value, float64(other.Value)), true
+	case *Float:
+		return compareFloats(f.Value, other.Value), true
+	}
+	return 0, false
+}
+
+// 文字列は辞書順で比較する
+func (s *String) Compare(other Object) (int, bool) {
+	if other, ok := other.(*String); ok {
+		return strings.Compare(s.Value, other.Value), true
+	}
+	return 0, false
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Equal は == の意味を定義する
+// 型が違う値は等しくない(ただし Integer と Float は数値として比較する)
+// コレクションは中身を再帰的に比較し、それ以外は同一性で比較する
+func Equal(a, b Object) bool {
+	return equal(a, b, nil)
+}
+
+// comparing は比較中のコレクションの組(コレクションを比べるときに作る)
+// 自分自身を含むコレクションで同じ組に戻ってきたら、その組は等しいとみなして止まる
+func equal(a, b Object, comparing map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if IsNumber(a) && IsNumber(b) {
+		switch a := a.(type) {
+		case *Integer:
+			if b, ok := b.(*Integer); ok {
+				return a.Value == b.Value
+			}
+			return float64(a.Value) == b.(*Float).Value
+		case *Float:
+			if b, ok := b.(*Integer); ok {
+				return a.Value == float64(b.Value)
+			}
+			return a.Value == b.(*Float).Value
+		}
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Nil:
+		return true
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if comparing == nil {
+			comparing = make(map[[2]Object]bool)
+		}
+		visit := [2]Object{a, b}
+		if comparing[visit] {
+			return true
+		}
+		comparing[visit] = true
+		defer delete(comparing, visit)
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], comparing) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		if comparing == nil {
+			comparing = make(map[[2]Object]bool)
+		}
+		visit := [2]Object{a, b}
+		if comparing[visit] {
+			return true
+		}
+		comparing[visit] = true
+		defer delete(comparing, visit)
+		for key, pair := range a.Pairs {
+			other, ok := b.Pairs[key]
+			if !ok || !equal(pair.Value, other.Value, comparing) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
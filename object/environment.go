@@ -0,0 +1,192 @@
+package object
+
+import "sort"
+
+// Environment は変数を保持するスコープ
+//
+// グローバルスコープ(NewEnvironment)は名前をキーにしたマップで変数を持つ
+// REPL やホストが後から変数を足せるようにするため
+//
+// ローカルスコープ(NewEnclosedEnvironment, NewFrame)は宣言された順に値をスライスに並べる
+// resolver が各識別子に (何個外側のスコープか, 何番目の変数か) を付けておけば、
+// GetAt で名前を比べずに値を取り出せる
+type Environment struct {
+	store  map[string]Object // グローバルスコープのときだけ使う
+	names  []string          // ローカル変数の名前(slot の順)
+	values []Object          // ローカル変数の値(slot の順)
+	shared bool              // names が resolver の作った共有のスライスかどうか
+	outer  *Environment
+}
+
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s}
+}
+
+// NewEnclosedEnvironment は outer の内側にローカルスコープを作る
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	return &Environment{outer: outer}
+}
+
+// NewFrame は resolver が求めた変数名の一覧を使ってローカルスコープを作る
+// locals は宣言される順に並んでいるので、Set のたびに名前を追加しなくてよい
+func NewFrame(outer *Environment, locals []string) *Environment {
+	if len(locals) == 0 {
+		return NewEnclosedEnvironment(outer)
+	}
+	return &Environment{
+		names:  locals,
+		values: make([]Object, 0, len(locals)),
+		shared: true,
+		outer:  outer,
+	}
+}
+
+// Set はこのスコープに変数を宣言する
+func (e *Environment) Set(name string, value Object) {
+	if e.store != nil {
+		e.store[name] = value
+		return
+	}
+	n := len(e.values)
+	if e.shared && n < len(e.names) && e.names[n] == name {
+		e.values = append(e.values, value)
+		return
+	}
+	if i := e.index(name); i >= 0 {
+		e.values[i] = value
+		return
+	}
+	if e.shared && (n >= len(e.names) || e.names[n] != name) {
+		// resolver の想定と違う順で宣言されたら、自分用の names に切り替える
+		e.names = append([]string(nil), e.names[:n]...)
+		e.shared = false
+	}
+	if !e.shared {
+		e.names = append(e.names, name)
+	}
+	e.values = append(e.values, value)
+}
+
+// index は宣言済みのローカル変数 name の位置を返す
+func (e *Environment) index(name string) int {
+	for i := range e.values {
+		if e.names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasOwn は外側のスコープを見ずに、このスコープで name が宣言されているかを返す
+func (e *Environment) HasOwn(name string) bool {
+	if e.store != nil {
+		_, ok := e.store[name]
+		return ok
+	}
+	return e.index(name) >= 0
+}
+
+// Assign は既に定義されている変数に値を代入する
+// 変数が見つからなければ false を返す
+func (e *Environment) Assign(name string, value Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if env.store != nil {
+			if _, ok := env.store[name]; ok {
+				env.store[name] = value
+				return true
+			}
+			continue
+		}
+		if i := env.index(name); i >= 0 {
+			env.values[i] = value
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if env.store != nil {
+			if obj, ok := env.store[name]; ok {
+				return obj, true
+			}
+			continue
+		}
+		if i := env.index(name); i >= 0 {
+			return env.values[i], true
+		}
+	}
+	return nil, false
+}
+
+// GetAt は depth 個外側のスコープの slot 番目の変数を返す
+// slot が負ならそのスコープ(グローバル)から名前で探す
+// まだ宣言されていない変数なら false を返すので、呼び出し側は Get で探し直す
+func (e *Environment) GetAt(depth, slot int, name string) (Object, bool) {
+	env := e.ancestor(depth)
+	if env == nil {
+		return nil, false
+	}
+	if slot < 0 {
+		if env.store == nil {
+			return nil, false
+		}
+		obj, ok := env.store[name]
+		return obj, ok
+	}
+	if slot >= len(env.values) || env.names[slot] != name {
+		return nil, false
+	}
+	return env.values[slot], true
+}
+
+// AssignAt は GetAt と同じ位置の変数に代入する
+func (e *Environment) AssignAt(depth, slot int, name string, value Object) bool {
+	env := e.ancestor(depth)
+	if env == nil {
+		return false
+	}
+	if slot < 0 {
+		if env.store == nil {
+			return false
+		}
+		if _, ok := env.store[name]; !ok {
+			return false
+		}
+		env.store[name] = value
+		return true
+	}
+	if slot >= len(env.values) || env.names[slot] != name {
+		return false
+	}
+	env.values[slot] = value
+	return true
+}
+
+func (e *Environment) ancestor(depth int) *Environment {
+	env := e
+	for i := 0; i < depth && env != nil; i++ {
+		env = env.outer
+	}
+	return env
+}
+
+// Outer は外側のスコープを返す
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names はこのスコープで宣言されている変数名を返す
+func (e *Environment) Names() []string {
+	if e.store != nil {
+		names := make([]string, 0, len(e.store))
+		for name := range e.store {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	return append([]string(nil), e.names[:len(e.values)]...)
+}
@@ -0,0 +1,137 @@
+package object
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+)
+
+// HashKey はハッシュのキーとして使う値
+// 等しい値からは同じ HashKey が得られ、等しくない値からは違う HashKey が得られる
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	Text  string // 文字列は値そのものをキーにし、ハッシュ値が衝突した別の文字列と混ざらないようにする
+}
+
+// Hashable はハッシュのキーにできるオブジェクトが実装する
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: INTEGER, Value: uint64(i.Value)}
+}
+
+// 1 == 1.0 なので、整数値の Float は Integer と同じキーになる
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+		return HashKey{Type: INTEGER, Value: uint64(int(f.Value))}
+	}
+	return HashKey{Type: FLOAT, Value: math.Float64bits(f.Value)}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: STRING, Text: s.Value}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: BOOLEAN, Value: value}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	keys  []HashKey // 挿入順を保持する
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH
+}
+
+func (h *Hash) String() string {
+	return h.inspect(make(map[Object]bool))
+}
+
+// inspect は表示中のコレクション(visiting)を {...} として表示し、自分自身を含むハッシュでも止まる
+func (h *Hash) inspect(visiting map[Object]bool) string {
+	if visiting[h] {
+		return "{...}"
+	}
+	visiting[h] = true
+	defer delete(visiting, h)
+
+	var out bytes.Buffer
+	out.WriteString("{")
+	for i, key := range h.keys {
+		pair := h.Pairs[key]
+		if i != 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(inspect(pair.Key, visiting))
+		out.WriteString(": ")
+		out.WriteString(inspect(pair.Value, visiting))
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+func (h *Hash) IsTruthy() bool {
+	return len(h.Pairs) != 0
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.keys = append(h.keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// Keys は挿入順にキーを返す
+func (h *Hash) Keys() []Object {
+	keys := make([]Object, 0, len(h.keys))
+	for _, key := range h.keys {
+		keys = append(keys, h.Pairs[key].Key)
+	}
+	return keys
+}
+
+// Inspect はコレクションの要素として表示するときの文字列を返す
+// 文字列はクォートで囲む
+func Inspect(obj Object) string {
+	return inspect(obj, make(map[Object]bool))
+}
+
+func inspect(obj Object, visiting map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *String:
+		return strconv.Quote(obj.Value)
+	case *Hash:
+		return obj.inspect(visiting)
+	case *Array:
+		return obj.inspect(visiting)
+	}
+	return obj.String()
+}
@@ -0,0 +1,34 @@
+package object
+
+// Module は名前空間にまとめた値の集まり
+// スクリプトからは strings.ToUpper("x") のように Name.member で参照する
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func NewModule(name string) *Module {
+	return &Module{Name: name, Members: make(map[string]Object)}
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE
+}
+
+func (m *Module) String() string {
+	return "module " + m.Name
+}
+
+func (m *Module) IsTruthy() bool {
+	return true
+}
+
+func (m *Module) Get(name string) (Object, bool) {
+	member, ok := m.Members[name]
+	return member, ok
+}
+
+// モジュールのメンバーはスクリプトから書き換えられない
+func (m *Module) Set(name string, value Object) *Error {
+	return NewError("cannot assign to %s.%s", m.Name, name)
+}
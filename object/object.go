@@ -24,6 +24,13 @@ const (
 	ERROR    ObjectType = "ERROR"
 	RETURN   ObjectType = "RETURN"
 	FUNCTION ObjectType = "FUNCTION"
+	HASH     ObjectType = "HASH"
+	ARRAY    ObjectType = "ARRAY"
+	CLASS    ObjectType = "CLASS"
+	INSTANCE ObjectType = "INSTANCE"
+	BUILTIN  ObjectType = "BUILTIN"
+	HOST     ObjectType = "HOST"
+	MODULE   ObjectType = "MODULE"
 )
 
 type Integer struct {
@@ -42,7 +49,25 @@ func (i *Integer) IsTruthy() bool {
 	return i.Value != 0
 }
 
+// 小さな整数はループの添字や剰余などで何度も作られるので、あらかじめ作って使い回す
+// 値は書き換えないので、同じ *Integer を共有しても問題ない
+const (
+	minCachedInteger = -128
+	maxCachedInteger = 1023
+)
+
+var integerCache = func() []Integer {
+	cache := make([]Integer, maxCachedInteger-minCachedInteger+1)
+	for i := range cache {
+		cache[i].Value = i + minCachedInteger
+	}
+	return cache
+}()
+
 func NewInteger(value int) *Integer {
+	if minCachedInteger <= value && value <= maxCachedInteger {
+		return &integerCache[value-minCachedInteger]
+	}
 	return &Integer{Value: value}
 }
 
@@ -102,8 +127,17 @@ func (s *String) IsTruthy() bool {
 	return len(s.Value) != 0
 }
 
+// true と false も nil と同じく一つずつしか作らない
+var (
+	TrueObject  = &Boolean{Value: true}
+	FalseObject = &Boolean{Value: false}
+)
+
 func NewBoolean(value bool) *Boolean {
-	return &Boolean{Value: value}
+	if value {
+		return TrueObject
+	}
+	return FalseObject
 }
 
 type Nil struct{}
@@ -120,12 +154,26 @@ func (n *Nil) IsTruthy() bool {
 	return false
 }
 
+// nil は一つしかないので、同一性で比較できるように使い回す
+var NilObject = &Nil{}
+
 func NewNil() *Nil {
-	return &Nil{}
+	return NilObject
 }
 
 type Error struct {
 	Message string
+	Line    int     // エラーが起きた行番号
+	Stack   []Frame // 外側の呼び出しから順に並んだスタックトレース
+}
+
+const maxTracebackFrames = 20
+
+// Frame はスタックトレースの一行分
+// Function の中の Line 行目を実行していたことを表す
+type Frame struct {
+	Function string
+	Line     int
 }
 
 func (e *Error) Type() ObjectType {
@@ -133,7 +181,23 @@ func (e *Error) Type() ObjectType {
 }
 
 func (e *Error) String() string {
-	return "ERROR: " + e.Message
+	if len(e.Stack) == 0 {
+		return "ERROR: " + e.Message
+	}
+	var out bytes.Buffer
+	out.WriteString("ERROR: " + e.Message + "\n")
+	out.WriteString("Traceback (most recent call last):")
+	for i, frame := range e.Stack {
+		// 深い再帰のトレースは先頭と末尾だけ表示する
+		if len(e.Stack) > maxTracebackFrames && i == maxTracebackFrames/2 {
+			out.WriteString(fmt.Sprintf("\n  ... %d more frames ...", len(e.Stack)-maxTracebackFrames))
+		}
+		if len(e.Stack) > maxTracebackFrames && i >= maxTracebackFrames/2 && i < len(e.Stack)-maxTracebackFrames/2 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\n  line %d, in %s", frame.Line, frame.Function))
+	}
+	return out.String()
 }
 
 type ReturnValue struct {
@@ -153,9 +217,45 @@ func (rv *ReturnValue) IsTruthy() bool {
 }
 
 type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	Name          string // 無名関数の場合は空文字
+	Parameters    []*ast.Identifier
+	Defaults      []ast.Expression // Parameters と同じ長さで、デフォルト値がない引数は nil
+	Rest          *ast.Identifier
+	Body          *ast.BlockStatement
+	Locals        []string // 呼び出しごとに作るスコープの変数名(resolver が求めたもの)
+	Env           *Environment
+	IsInitializer bool // クラスの init メソッドかどうか
+}
+
+// Bind は this をインスタンスに束縛したメソッドを返す
+func (f *Function) Bind(instance *Instance) *Function {
+	env := NewEnclosedEnvironment(f.Env)
+	env.Set("this", instance)
+	bound := *f
+	bound.Env = env
+	return &bound
+}
+
+func (f *Function) BindMethod(instance *Instance) Object {
+	return f.Bind(instance)
+}
+
+// DisplayName はエラーメッセージ用の関数名を返す
+func (f *Function) DisplayName() string {
+	if f.Name == "" {
+		return "<anonymous>"
+	}
+	return f.Name
+}
+
+// MinArity は省略できない引数の数を返す
+func (f *Function) MinArity() int {
+	for i, d := range f.Defaults {
+		if d != nil {
+			return i
+		}
+	}
+	return len(f.Parameters)
 }
 
 func (f *Function) Type() ObjectType {
@@ -163,17 +263,22 @@ func (f *Function) Type() ObjectType {
 }
 
 func (f *Function) String() string {
+	return FunctionSource(f.Parameters, f.Body)
+}
+
+// FunctionSource は関数を表示するときの文字列を返す
+// vm のクロージャも同じ表示にするためにコンパイラから使う
+func FunctionSource(parameters []*ast.Identifier, body *ast.BlockStatement) string {
 	var out bytes.Buffer
 	out.WriteString("fn(")
-	for i, p := range f.Parameters {
+	for i, p := range parameters {
 		out.WriteString(p.String())
-		if i != len(f.Parameters)-1 {
+		if i != len(parameters)-1 {
 			out.WriteString(", ")
 		}
 	}
-	out.WriteString(") {\n")
-	out.WriteString(f.Body.String())
-	out.WriteString("\n}")
+	out.WriteString(") ")
+	out.WriteString(body.String())
 	return out.String()
 }
 
@@ -192,32 +297,3 @@ func NewError(format string, a ...interface{}) *Error {
 func IsNumber(obj Object) bool {
 	return obj.Type() == INTEGER || obj.Type() == FLOAT
 }
-
-type Environment struct {
-	store map[string]Object
-	outer *Environment
-}
-
-func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{store: s}
-}
-
-func (e *Environment) Set(name string, value Object) {
-	e.store[name] = value
-}
-
-func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok := e.outer.Get(name)
-		return obj, ok
-	}
-	return obj, ok
-}
-
-func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
-}
@@ -0,0 +1,84 @@
+package object
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNewIntegerCache(t *testing.T) {
+	for _, value := range []int{minCachedInteger - 1, minCachedInteger, -1, 0, 1, maxCachedInteger, maxCachedInteger + 1} {
+		if got := NewInteger(value).Value; got != value {
+			t.Errorf("NewInteger(%d).Value = %d", value, got)
+		}
+	}
+	if NewInteger(7) != NewInteger(7) {
+		t.Errorf("small integers should be shared")
+	}
+	if NewInteger(maxCachedInteger+1) == NewInteger(maxCachedInteger+1) {
+		t.Errorf("large integers should not be shared")
+	}
+}
+
+func TestNewBooleanSingletons(t *testing.T) {
+	if NewBoolean(true) != TrueObject || NewBoolean(false) != FalseObject {
+		t.Errorf("NewBoolean should return the shared true and false")
+	}
+	if !Equal(NewBoolean(true), &Boolean{Value: true}) {
+		t.Errorf("booleans should still compare by value")
+	}
+}
+
+var sink Object
+
+// 半分はキャッシュの範囲内、半分は範囲外の整数を作る
+func BenchmarkNewInteger(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		sink = NewInteger(n % 2048)
+	}
+}
+
+// 文字列のキーは値そのもので比べるので、ハッシュ値が衝突しても別のキーになる
+func TestHashStringKeys(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 100000; i++ {
+		h.Set(NewString(strconv.Itoa(i)), NewInteger(i))
+	}
+	h.Set(NewInteger(1), NewString("int"))
+	h.Set(NewBoolean(true), NewString("bool"))
+	h.Set(NewString("true"), NewString("string"))
+	if got := len(h.Keys()); got != 100003 {
+		t.Errorf("expected 100003 keys, but got %d", got)
+	}
+	for i := 0; i < 100000; i += 997 {
+		if value, ok := h.Get(NewString(strconv.Itoa(i))); !ok || value.(*Integer).Value != i {
+			t.Errorf("key %d: got %v", i, value)
+		}
+	}
+	if value, _ := h.Get(NewString("1")); value.(*Integer).Value != 1 {
+		t.Errorf(`"1" should not collide with 1`)
+	}
+	if value, _ := h.Get(NewString("true")); value.String() != "string" {
+		t.Errorf(`"true" should not collide with true`)
+	}
+}
+
+func TestHashStringCycle(t *testing.T) {
+	inner := NewHash()
+	outer := NewHash()
+	inner.Set(NewString("outer"), outer)
+	outer.Set(NewString("a"), inner)
+	outer.Set(NewString("b"), inner)
+	want := `{"a": {"outer": {...}}, "b": {"outer": {...}}}`
+	if got := outer.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestArrayStringCycle(t *testing.T) {
+	a := NewArray([]Object{NewInteger(1)})
+	a.Elements = append(a.Elements, a, NewArray([]Object{a}))
+	if got, want := a.String(), "[1, [...], [[...]]]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
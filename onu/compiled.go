@@ -0,0 +1,91 @@
+package onu
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-interpreter-practice/compiler"
+)
+
+// CompiledExt はコンパイル済みファイルの拡張子
+const CompiledExt = ".onuc"
+
+// CompileFile は path のスクリプトをコンパイルし、コンパイル済みファイル out に書き出す
+// out には out から見た path の相対パスを記録し、LoadCompiled でソースの更新を確認するのに使う
+func (i *Interpreter) CompileFile(path, out string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	bytecode, err := i.CompileBytecode(path, string(src))
+	if err != nil {
+		return err
+	}
+	source, err := filepath.Rel(filepath.Dir(out), path)
+	if err != nil {
+		if source, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	if err := compiler.Encode(&buf, bytecode, filepath.ToSlash(source)); err != nil {
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0644)
+}
+
+// LoadCompiled はコンパイル済みファイルを読み込み、バイトコードとソースのファイル名を返す
+// 次の場合は記録されたソースからコンパイルし直す
+//   - ソースがコンパイル済みファイルより新しい
+//   - ファイルが壊れている、または形式のバージョンが違う(ソースが見つかる場合のみ)
+//
+// コンパイルし直してもコンパイル済みファイルは書き換えない
+func (i *Interpreter) LoadCompiled(path string) (*compiler.Bytecode, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	bytecode, source, err := compiler.Decode(data)
+	if err != nil {
+		// 壊れたファイルに記録されたファイル名は信用できないので、foo.onuc なら foo.onu を探す
+		if filepath.Ext(path) == CompiledExt {
+			source = strings.TrimSuffix(path, CompiledExt) + ".onu"
+			if _, statErr := os.Stat(source); statErr == nil {
+				return i.recompile(source)
+			}
+		}
+		return nil, "", fmt.Errorf("%s: %v", path, err)
+	}
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(filepath.Dir(path), filepath.FromSlash(source))
+	}
+	if newer, _ := isNewer(source, path); newer {
+		return i.recompile(source)
+	}
+	return bytecode, source, nil
+}
+
+func (i *Interpreter) recompile(source string) (*compiler.Bytecode, string, error) {
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return nil, "", err
+	}
+	bytecode, err := i.CompileBytecode(source, string(src))
+	return bytecode, source, err
+}
+
+// isNewer は a が b より後に更新されていれば true を返す
+func isNewer(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return aInfo.ModTime().After(bInfo.ModTime()), nil
+}
@@ -0,0 +1,89 @@
+package onu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadAndRun はコンパイル済みファイルを読み込んで実行し、出力とソースのファイル名を返す
+func loadAndRun(t *testing.T, path string) (string, string) {
+	t.Helper()
+	var out bytes.Buffer
+	i := New()
+	i.SetStdout(&out)
+	bytecode, source, err := i.LoadCompiled(path)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if _, err := i.RunBytecode(context.Background(), source, bytecode); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	return out.String(), source
+}
+
+func TestCompileFileAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.onu")
+	compiled := filepath.Join(dir, "build", "main.onuc")
+	old := time.Now().Add(-time.Hour)
+	writeFile(t, source, `func greet(name) { print "hello " + name }
+greet("onu")`, old)
+	if err := os.Mkdir(filepath.Dir(compiled), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().CompileFile(source, compiled); err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+
+	out, name := loadAndRun(t, compiled)
+	if out != "hello onu\n" || name != source {
+		t.Errorf("got %q from %s, want %q from %s", out, name, "hello onu\n", source)
+	}
+
+	// ソースの方が新しければコンパイルし直す
+	writeFile(t, source, `print "updated"`, time.Now().Add(time.Hour))
+	if out, _ := loadAndRun(t, compiled); out != "updated\n" {
+		t.Errorf("got %q, want the recompiled output", out)
+	}
+}
+
+func TestLoadCompiledBrokenFile(t *testing.T) {
+	dir := t.TempDir()
+	compiled := filepath.Join(dir, "main.onuc")
+	old := time.Now().Add(-time.Hour)
+	writeFile(t, compiled, "broken", time.Now())
+
+	// ソースがなければエラー
+	if _, _, err := New().LoadCompiled(compiled); err == nil {
+		t.Fatal("expected an error for a broken file")
+	}
+
+	// 同じ名前のソースがあればコンパイルし直す
+	writeFile(t, filepath.Join(dir, "main.onu"), `print "from source"`, old)
+	if out, _ := loadAndRun(t, compiled); out != "from source\n" {
+		t.Errorf("got %q, want the output of the source", out)
+	}
+
+	// ソースに誤りがあれば *CompileError
+	writeFile(t, filepath.Join(dir, "main.onu"), `print undefined`, old)
+	_, _, err := New().LoadCompiled(compiled)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Errorf("got %v, want *CompileError", err)
+	}
+}
@@ -0,0 +1,373 @@
+package onu
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+)
+
+type backendConfig struct {
+	name     string
+	backend  Backend
+	optimize bool
+}
+
+func (c backendConfig) new() *Interpreter {
+	i := New()
+	i.SetBackend(c.backend)
+	i.SetOptimize(c.optimize)
+	return i
+}
+
+// 最適化してもしなくても同じ結果になることも確かめる
+var backends = []backendConfig{
+	{"eval", TreeWalker, false},
+	{"vm", BytecodeVM, false},
+	{"eval -O", TreeWalker, true},
+	{"vm -O", BytecodeVM, true},
+}
+
+// runWith は src を backend で実行し、出力と最後の値(またはエラー)をつなげて返す
+func runWith(backend backendConfig, src string, setup func(*Interpreter)) string {
+	var out bytes.Buffer
+	i := backend.new()
+	i.SetStdout(&out)
+	if setup != nil {
+		setup(i)
+	}
+	result, err := i.Run(context.Background(), "test.onu", src)
+	if err != nil {
+		out.WriteString(err.Error())
+	} else if result != nil && result != object.NilObject {
+		out.WriteString(result.String())
+	}
+	return out.String()
+}
+
+// どちらのバックエンドで実行しても出力、値、エラーメッセージとスタックトレースが同じになる
+func TestBackendConformance(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"arithmetic", "print 7 / 2\nprint -7 / 2\nprint -7 % 3\nprint 7.5 % -2\nprint 1 + 2.5\n2 * 3 - 1", "3\n-4\n2\n-0.5\n3.5\n5"},
+		{"empty program", "", ""},
+		{"unterminated expression", "var a = 1\nvar b = (a +", "test.onu: line 2; no prefix parse function for \ntest.onu: line 2; expected ')', but got "},
+		{"strings", `"a" + "b" == "ab"`, "true"},
+		{"comparison", `print 1 < 2.5
+print "a" < "b"
+print [1, 2] == [1, 2]
+1 != 1.0`, "true\ntrue\ntrue\nfalse"},
+		{"truthiness", `print !0
+print !""
+print ![]
+!nil`, "true\ntrue\ntrue\ntrue"},
+		{"integer overflow", "9223372036854775807 + 1", "test.onu: ERROR: integer overflow: 9223372036854775807 + 1\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"negate min int", "var min = -9223372036854775807 - 1\n-min", "test.onu: ERROR: integer overflow: -(-9223372036854775808)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"divide min int by minus one", "var min = -9223372036854775807 - 1\nprint min % -1\nmin / -1", "0\ntest.onu: ERROR: integer overflow: -9223372036854775808 / -1\nTraceback (most recent call last):\n  line 3, in <main>"},
+		{"modulo by zero", "1 % 0", "test.onu: ERROR: modulo by zero\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"division by zero", "1.5 / 0", "test.onu: ERROR: division by zero\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"unknown operator", `"a" - 1`, "test.onu: ERROR: unknown operator: STRING - INTEGER\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"if expression", "var a = if (false) { 1 }\nvar b = if (1 < 2) { var x = 3; x * 2 } else { 0 }\nprint a\nb", "nil\n6"},
+		{"block scope", "var x = 1\nif (true) { var x = 2; print x }\nx", "2\n1"},
+		{"closure counter", `func counter() {
+  var count = 0
+  return func() { count = count + 1; return count }
+}
+var next = counter()
+next()
+next()
+next()`, "3"},
+		{"closures capture each iteration", `var fs = {}
+for (i in [1, 2, 3]) {
+  var doubled = i * 2
+  fs[i] = func() { return doubled + i }
+}
+fs[1]() + fs[2]() + fs[3]()`, "18"},
+		{"closure sees later declaration", `func outer() {
+  func inner() { return y }
+  var y = 10
+  return inner()
+}
+outer()`, "10"},
+		{"read before declaration uses outer", `var y = 1
+func f() {
+  var before = y
+  var y = 2
+  return before + y
+}
+f()`, "3"},
+		{"hoisting and mutual recursion", `print isEven(10)
+func isEven(n) { if (n == 0) { return true } return isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false } return isEven(n - 1) }`, "true\n"},
+		{"named function expression", "var f = func fact(n) { if (n == 0) { return 1 } return n * fact(n - 1) }\nf(5)", "120"},
+		{"implicit return", "func f(x) { if (x) { \"yes\" } else { \"no\" } }\nprint f(true)\nfunc g() { var a = 1 }\nprint g()", "yes\nnil\n"},
+		{"defaults and rest", `func f(a, b = a * 2, ...rest) { return [a, b, rest] }
+print f(1)
+print f(1, 5, 6, 7)`, "[1, 2, []]\n[1, 5, [6, 7]]\n"},
+		{"arity errors", `func f(a, b = 1) {}
+f()`, "test.onu: ERROR: wrong number of arguments: f expects 1 to 2, got 0 (called at line 2)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"rest arity", "var g = func(a, ...rest) { return a }\ng()", "test.onu: ERROR: wrong number of arguments: g expects at least 1, got 0 (called at line 2)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"not a function", "var x = 1\nx()", "test.onu: ERROR: not a function INTEGER\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"stack trace", `func a() {
+  return b() + 1
+}
+func b() {
+  var x = [1, 2]
+  return x[5]
+}
+print "start"
+a()`, "start\ntest.onu: ERROR: index out of range: 5 (length 2)\nTraceback (most recent call last):\n  line 9, in <main>\n  line 2, in a\n  line 6, in b"},
+		{"tail call trace", `func a(n) { return b(n) }
+func b(n) { return 1 / n }
+a(0)`, "test.onu: ERROR: division by zero\nTraceback (most recent call last):\n  line 3, in <main>\n  line 2, in b"},
+		{"deep tail recursion", `func loop(n, acc) {
+  if (n == 0) { return acc }
+  return loop(n - 1, acc + 1)
+}
+loop(1000000, 0)`, "1000000"},
+		{"recursion limit", `func down(n) { return 1 + down(n + 1) }
+down(0)`, "maximum recursion depth exceeded (10000)"},
+		{"classes", `class Point {
+  init(x, y) { this.x = x; this.y = y }
+  sum() { return this.x + this.y }
+}
+class Point3 < Point {
+  init(x, y, z) { super.init(x, y); this.z = z }
+  sum() { return super.sum() + this.z }
+}
+var p = Point3(1, 2, 3)
+print p.sum()
+print p
+print type(p)
+p.init(4, 5, 6) == p`, "6\nPoint3 instance\ninstance\ntrue"},
+		{"method closure keeps this", `class C {
+  init() { this.n = 1 }
+  adder() { return func(k) { return this.n + k } }
+}
+var add = C().adder()
+add(41)`, "42"},
+		{"class errors", `class A {}
+A(1)`, "test.onu: ERROR: wrong number of arguments: A expects 0, got 1 (called at line 2)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"method arity", `class A { init(x) {} }
+A()`, "test.onu: ERROR: wrong number of arguments: A.init expects 1, got 0 (called at line 2)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"undefined property", `class A {}
+A().missing`, "test.onu: ERROR: undefined property missing\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"fields on non instance", "var x = 1\nx.y = 2", "test.onu: ERROR: only instances have fields: INTEGER\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"superclass must be a class", "var x = 1\nclass A < x {}", "test.onu: ERROR: superclass must be a class: INTEGER\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"hashes and arrays", `var h = {"b": 2, "a": 1}
+h["c"] = 3
+var keys = []
+for (k in h) { print k }
+var a = [1, 2, 3]
+a[0] = 10
+print a
+print h["missing"]
+{true: 1}[true]`, "b\na\nc\n[10, 2, 3]\nnil\n1"},
+		{"self referential hash", `var h = {"n": 1}
+h["self"] = h
+print h
+h["self"]["self"]["n"]`, "{\"n\": 1, \"self\": {...}}\n1"},
+		{"self referential array", `var a = [1]
+var h = {"a": a}
+a[0] = h
+print a
+print h`, "[{\"a\": [...]}]\n{\"a\": [{...}]}\n"},
+		{"self referential equality", `var a = [1]
+a[0] = a
+var b = [1]
+b[0] = b
+var h = {}
+h["self"] = h
+var g = {}
+g["self"] = g
+print a == b
+print h == g
+a == [a, 1]`, "true\ntrue\nfalse"},
+		{"index errors", `[1][1.5]`, "test.onu: ERROR: array index must be INTEGER, got FLOAT\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"unusable hash key", `{[1]: 2}`, "test.onu: ERROR: unusable as hash key: ARRAY\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"not iterable", "for (x in 1) {}", "test.onu: ERROR: not iterable: INTEGER\nTraceback (most recent call last):\n  line 1, in <main>"},
+		{"redeclaration", "var a = 1\nvar b = 2\nvar a = 3", "test.onu: line 3; a is already declared in this scope (line 1)"},
+		{"redeclaration in function", "func f(x) {\n  var y = 1\n  func y() {}\n  var x = 2\n}", "test.onu: line 2; y is already declared in this scope (line 3)\ntest.onu: line 4; x is already declared in this scope (line 1)"},
+		{"builtins", `print len("héllo")
+print type(len)
+print str(1.5) + "!"
+print range(3)
+println("a", 1)
+assert(false, "boom")`, "5\nbuiltin\n1.5!\n[0, 1, 2]\na 1\ntest.onu: ERROR: assertion failed: boom\nTraceback (most recent call last):\n  line 6, in <main>"},
+		{"range overflow", "print range(9223372036854775806, 9223372036854775807)\nrange(0, 9223372036854775807)", "[9223372036854775806]\ntest.onu: ERROR: range: too many elements (9223372036854775807, limit 16777216)\nTraceback (most recent call last):\n  line 2, in <main>"},
+		{"print statement and write", `write("a", 1)
+print "b"
+print (1 + 2) * 3
+print (4)
+var w = write
+w("d")`, "a 1b\n9\n4\nd"},
+		{"function values", "func f(a, b) { return a }\nprint type(f)\nf == f", "function\ntrue"},
+		{"return outside function", "var x = 1\nreturn x + 1", "test.onu: line 2; return outside of a function"},
+	}
+	for _, tt := range tests {
+		for _, b := range backends {
+			got := runWith(b, tt.input, nil)
+			if tt.name == "recursion limit" {
+				// トレースが長いので先頭だけ比べる
+				if !strings.Contains(got, tt.expected) {
+					t.Errorf("%s [%s]: got %q, want it to contain %q", tt.name, b.name, got, tt.expected)
+				}
+				continue
+			}
+			if got != tt.expected {
+				t.Errorf("%s [%s]:\ngot:\n%s\nwant:\n%s", tt.name, b.name, got, tt.expected)
+			}
+		}
+	}
+}
+
+// 実行時に見つかる誤りはどちらのバックエンドでも同じエラーになる
+func TestBackendConformanceRuntimeRedeclaration(t *testing.T) {
+	for _, b := range backends {
+		i := b.new()
+		if _, err := i.Run(context.Background(), "a.onu", "var x = 1"); err != nil {
+			t.Fatalf("[%s] unexpected error: %v", b.name, err)
+		}
+		_, err := i.Run(context.Background(), "b.onu", "\nvar x = 2")
+		want := "b.onu: ERROR: x is already declared in this scope (line 2)\nTraceback (most recent call last):\n  line 2, in <main>"
+		if err == nil || err.Error() != want {
+			t.Errorf("[%s] got %v, want %q", b.name, err, want)
+		}
+	}
+}
+
+func TestBackendConformanceHost(t *testing.T) {
+	for _, b := range backends {
+		var out bytes.Buffer
+		i := b.new()
+		i.SetStdout(&out)
+		i.SetGlobal("base", object.NewInteger(10))
+		i.RegisterBuiltin("twice", func(args ...object.Object) object.Object {
+			return object.NewInteger(args[0].(*object.Integer).Value * 2)
+		})
+		src := `
+func scale(n, k = 1) { return twice(base * n) * k }
+class Box { init(v) { this.v = v } }
+`
+		if _, err := i.Run(context.Background(), "a.onu", src); err != nil {
+			t.Fatalf("[%s] unexpected error: %v", b.name, err)
+		}
+		result, err := i.Call("scale", object.NewInteger(4))
+		if err != nil || result.String() != "80" {
+			t.Errorf("[%s] scale(4) = %v, %v", b.name, result, err)
+		}
+		box, err := i.Call("Box", object.NewInteger(7))
+		if err != nil || box.String() != "Box instance" {
+			t.Errorf("[%s] Box(7) = %v, %v", b.name, box, err)
+		}
+		_, err = i.Call("scale")
+		want := "call scale: ERROR: wrong number of arguments: scale expects 1 to 2, got 0 (called at line 0)"
+		if err == nil || err.Error() != want {
+			t.Errorf("[%s] got %v, want %q", b.name, err, want)
+		}
+		// 前の Run で定義した関数を次の Run から呼べる
+		result, err = i.Run(context.Background(), "b.onu", "scale(1, 3)")
+		if err != nil || result.String() != "60" {
+			t.Errorf("[%s] scale(1, 3) = %v, %v", b.name, result, err)
+		}
+	}
+}
+
+func TestBackendConformanceLimits(t *testing.T) {
+	for _, b := range backends {
+		i := b.new()
+		i.SetStdout(&bytes.Buffer{})
+		i.SetLimits(evaluator.Limits{MaxOutputBytes: 10})
+		_, err := i.Run(context.Background(), "a.onu", `for (x in range(100)) { print "hello" }`)
+		if err == nil || !strings.Contains(err.Error(), evaluator.OutputLimitExceeded) {
+			t.Errorf("[%s] got %v, want output limit error", b.name, err)
+		}
+
+		i.SetLimits(evaluator.Limits{MaxSteps: 1000})
+		_, err = i.Run(context.Background(), "b.onu", `func spin(n) { return spin(n + 1) }
+spin(0)`)
+		if err == nil || !strings.Contains(err.Error(), evaluator.StepLimitExceeded) {
+			t.Errorf("[%s] got %v, want step limit error", b.name, err)
+		}
+
+		i.SetLimits(evaluator.Limits{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = i.Run(ctx, "c.onu", `for (x in range(10)) {}`)
+		if err == nil || !strings.Contains(err.Error(), evaluator.ExecutionCancelled) {
+			t.Errorf("[%s] got %v, want cancellation error", b.name, err)
+		}
+	}
+}
+
+const benchmarkProgram = `
+func fib(n) { if (n < 2) { return n } return fib(n - 1) + fib(n - 2) }
+func sum(items) {
+  var total = 0
+  for (x in items) { total = total + x }
+  return total
+}
+fib(20) + sum(range(10000))
+`
+
+// go test ./onu -bench Backend でバックエンドと最適化の有無による速さを比べる
+func BenchmarkBackend(b *testing.B) {
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			program, err := backend.new().Compile("bench.onu", benchmarkProgram)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				// 同じグローバル環境で関数を宣言し直せないので、毎回新しい Interpreter で実行する
+				i := backend.new()
+				if _, err := i.RunProgram(context.Background(), "bench.onu", program); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+const arithmeticProgram = `
+func loop(n) {
+  var total = 0
+  var ratio = 0.0
+  for (i in range(n)) {
+    var small = i % 100
+    total = total + small * 2 - 1
+    ratio = ratio + small / 3.0
+    if (small < 50 == true) { total = total + 1 }
+  }
+  return [total, ratio]
+}
+loop(20000)
+`
+
+// go test ./onu -bench Arithmetic -benchmem で整数、浮動小数点数、真偽値を作る回数を比べる
+func BenchmarkArithmetic(b *testing.B) {
+	for _, backend := range backends[:2] {
+		b.Run(backend.name, func(b *testing.B) {
+			program, err := backend.new().Compile("bench.onu", arithmeticProgram)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				i := backend.new()
+				if _, err := i.RunProgram(context.Background(), "bench.onu", program); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
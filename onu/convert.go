@@ -0,0 +1,504 @@
+package onu
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"go-interpreter-practice/object"
+)
+
+var (
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	objectType    = reflect.TypeOf((*object.Object)(nil)).Elem()
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// converter は変換中のマップ、スライス、ポインタと onu の配列、ハッシュを覚えておき、
+// 自分自身を含む値を変換しようとしたら無限に再帰する代わりにエラーを返す
+type converter struct {
+	values  map[visit]bool
+	objects map[object.Object]bool
+}
+
+// visit は変換中の Go の値
+// 同じ配列を指していても長さや型が違うスライスは別の値として扱う
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enterValue は v を変換中として記録し、記録を消す関数を返す
+func (c *converter) enterValue(v reflect.Value) (func(), error) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if c.values[key] {
+		return nil, fmt.Errorf("cannot convert cyclic %s", v.Type())
+	}
+	if c.values == nil {
+		c.values = make(map[visit]bool)
+	}
+	c.values[key] = true
+	return func() { delete(c.values, key) }, nil
+}
+
+// enterObject は配列やハッシュ obj を変換中として記録し、記録を消す関数を返す
+func (c *converter) enterObject(obj object.Object) (func(), error) {
+	if c.objects[obj] {
+		return nil, fmt.Errorf("cannot convert cyclic %s", obj.Type())
+	}
+	if c.objects == nil {
+		c.objects = make(map[object.Object]bool)
+	}
+	c.objects[obj] = true
+	return func() { delete(c.objects, obj) }, nil
+}
+
+// ToObject は Go の値を onu のオブジェクトに変換する
+//
+//   - bool, 整数, 浮動小数点数, string はそれぞれ Boolean, Integer, Float, String になる
+//   - スライスと配列は Array、マップは Hash になる
+//   - 構造体はエクスポートされたフィールドを持つ Hash になる(値のコピー)
+//   - 構造体へのポインタは GoObject になり、フィールドの読み書きとメソッド呼び出しができる
+//   - 関数は組み込み関数になる
+//   - nil は nil になり、object.Object はそのまま返す
+//   - 自分自身を含むマップ、スライス、ポインタはエラーになる
+func ToObject(v interface{}) (object.Object, error) {
+	if v == nil {
+		return object.NewNil(), nil
+	}
+	if obj, ok := v.(object.Object); ok {
+		return obj, nil
+	}
+	return valueToObject(reflect.ValueOf(v))
+}
+
+func valueToObject(v reflect.Value) (object.Object, error) {
+	return new(converter).toObject(v)
+}
+
+func (c *converter) toObject(v reflect.Value) (object.Object, error) {
+	if !v.IsValid() {
+		return object.NewNil(), nil
+	}
+	if v.Type().Implements(objectType) && v.Kind() != reflect.Interface {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return object.NewNil(), nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return object.NewBoolean(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return object.NewInteger(int(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt {
+			return nil, fmt.Errorf("%d overflows onu integer", v.Uint())
+		}
+		return object.NewInteger(int(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return object.NewFloat(v.Float()), nil
+	case reflect.String:
+		return object.NewString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return object.NewNil(), nil
+		}
+		if v.Kind() == reflect.Slice {
+			leave, err := c.enterValue(v)
+			if err != nil {
+				return nil, err
+			}
+			defer leave()
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := c.toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return object.NewArray(elements), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return object.NewNil(), nil
+		}
+		leave, err := c.enterValue(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		// Hash は挿入順を保つので、実行のたびに順序が変わらないようキーを並べてから入れる
+		hash := object.NewHash()
+		for _, k := range sortedKeys(v) {
+			key, err := c.toObject(k)
+			if err != nil {
+				return nil, err
+			}
+			hashKey, ok := key.(object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			value, err := c.toObject(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashKey, value)
+		}
+		return hash, nil
+	case reflect.Struct:
+		hash := object.NewHash()
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			value, err := c.toObject(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(object.NewString(field.Name), value)
+		}
+		return hash, nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return object.NewNil(), nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return &GoObject{value: v}, nil
+		}
+		leave, err := c.enterValue(v)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		return c.toObject(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return object.NewNil(), nil
+		}
+		return c.toObject(v.Elem())
+	case reflect.Func:
+		if v.IsNil() {
+			return object.NewNil(), nil
+		}
+		return wrapFunc(v.Type().String(), v), nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to onu value", v.Type())
+}
+
+// FromObject は onu のオブジェクトを ptr の指す Go の変数に代入する
+// 型が合わない場合や整数が範囲外の場合はエラーを返す
+func FromObject(obj object.Object, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("FromObject needs a non-nil pointer, got %T", ptr)
+	}
+	converted, err := objectToValue(obj, v.Type().Elem())
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(converted)
+	return nil
+}
+
+// ToValue は onu のオブジェクトを自然な Go の値に変換する
+// Integer は int、Float は float64、Array は []interface{}、Hash は map[string]interface{} になる
+// 自分自身を含む配列やハッシュなど、変換できないときは obj をそのまま返す
+func ToValue(obj object.Object) interface{} {
+	var v interface{}
+	if err := FromObject(obj, &v); err != nil {
+		return obj
+	}
+	return v
+}
+
+// objectToValue は obj を t 型の Go の値に変換する
+func objectToValue(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	return new(converter).toValue(obj, t)
+}
+
+func (c *converter) toValue(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if obj == nil {
+		obj = object.NewNil()
+	}
+	if goObj, ok := obj.(*GoObject); ok && goObj.value.Type().AssignableTo(t) {
+		return goObj.value, nil
+	}
+	if reflect.TypeOf(obj).AssignableTo(t) && t != interfaceType {
+		return reflect.ValueOf(obj), nil
+	}
+	if obj == object.NilObject {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, conversionError(obj, t)
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return c.natural(obj)
+		}
+		if goObj, ok := obj.(*GoObject); ok && goObj.value.Type().Implements(t) {
+			return goObj.value.Convert(t), nil
+		}
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			return reflect.ValueOf(b.Value).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			v := reflect.New(t).Elem()
+			if v.OverflowInt(int64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetInt(int64(i.Value))
+			return v, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			v := reflect.New(t).Elem()
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetUint(uint64(i.Value))
+			return v, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Float:
+			return reflect.ValueOf(n.Value).Convert(t), nil
+		case *object.Integer:
+			return reflect.ValueOf(float64(n.Value)).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			return reflect.ValueOf(s.Value).Convert(t), nil
+		}
+	case reflect.Slice:
+		if a, ok := obj.(*object.Array); ok {
+			leave, err := c.enterObject(a)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			defer leave()
+			v := reflect.MakeSlice(t, len(a.Elements), len(a.Elements))
+			for i, element := range a.Elements {
+				converted, err := c.toValue(element, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Index(i).Set(converted)
+			}
+			return v, nil
+		}
+	case reflect.Array:
+		if a, ok := obj.(*object.Array); ok {
+			if len(a.Elements) != t.Len() {
+				return reflect.Value{}, fmt.Errorf("array of length %d cannot be converted to %s", len(a.Elements), t)
+			}
+			leave, err := c.enterObject(a)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			defer leave()
+			v := reflect.New(t).Elem()
+			for i, element := range a.Elements {
+				converted, err := c.toValue(element, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Index(i).Set(converted)
+			}
+			return v, nil
+		}
+	case reflect.Map:
+		if h, ok := obj.(*object.Hash); ok {
+			leave, err := c.enterObject(h)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			defer leave()
+			keys := h.Keys()
+			v := reflect.MakeMapWithSize(t, len(keys))
+			for _, key := range keys {
+				k, err := c.toValue(key, t.Key())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				if v.MapIndex(k).IsValid() {
+					return reflect.Value{}, fmt.Errorf("hash key %s collides with another key as %s", object.Inspect(key), t.Key())
+				}
+				value, _ := h.Get(key.(object.Hashable))
+				converted, err := c.toValue(value, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.SetMapIndex(k, converted)
+			}
+			return v, nil
+		}
+	case reflect.Struct:
+		switch o := obj.(type) {
+		case *object.Hash:
+			leave, err := c.enterObject(o)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			defer leave()
+			v := reflect.New(t).Elem()
+			for _, key := range o.Keys() {
+				name, ok := key.(*object.String)
+				if !ok {
+					return reflect.Value{}, fmt.Errorf("%s has no field %s", t, object.Inspect(key))
+				}
+				field, ok := t.FieldByName(name.Value)
+				if !ok || !field.IsExported() {
+					return reflect.Value{}, fmt.Errorf("%s has no field %s", t, name.Value)
+				}
+				value, _ := o.Get(name)
+				converted, err := c.toValue(value, field.Type)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("field %s: %w", name.Value, err)
+				}
+				v.FieldByIndex(field.Index).Set(converted)
+			}
+			return v, nil
+		case *GoObject:
+			if o.value.Elem().Type().AssignableTo(t) {
+				return o.value.Elem(), nil
+			}
+		}
+	case reflect.Pointer:
+		if _, ok := obj.(*GoObject); !ok {
+			elem, err := c.toValue(obj, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(t.Elem())
+			v.Elem().Set(elem)
+			return v, nil
+		}
+	}
+	return reflect.Value{}, conversionError(obj, t)
+}
+
+// natural は型の指定がないときの変換
+func (c *converter) natural(obj object.Object) (reflect.Value, error) {
+	var v interface{}
+	switch o := obj.(type) {
+	case *object.Integer:
+		v = o.Value
+	case *object.Float:
+		v = o.Value
+	case *object.String:
+		v = o.Value
+	case *object.Boolean:
+		v = o.Value
+	case *object.Array:
+		leave, err := c.enterObject(o)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		defer leave()
+		elements := make([]interface{}, len(o.Elements))
+		for i, element := range o.Elements {
+			converted, err := c.toValue(element, interfaceType)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elements[i] = converted.Interface()
+		}
+		v = elements
+	case *object.Hash:
+		// 文字列以外のキーは String() で文字列にする
+		// 1 と "1" のように同じ文字列になるキーがあれば、どちらかを黙って捨てずにエラーにする
+		leave, err := c.enterObject(o)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		defer leave()
+		keys := o.Keys()
+		m := make(map[string]interface{}, len(keys))
+		for _, k := range keys {
+			key := k.String()
+			if s, ok := k.(*object.String); ok {
+				key = s.Value
+			}
+			if _, ok := m[key]; ok {
+				return reflect.Value{}, fmt.Errorf("hash key %s collides with another key as string %q", object.Inspect(k), key)
+			}
+			value, _ := o.Get(k.(object.Hashable))
+			converted, err := c.toValue(value, interfaceType)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			m[key] = converted.Interface()
+		}
+		v = m
+	case *GoObject:
+		v = o.value.Interface()
+	default:
+		v = obj
+	}
+	value := reflect.New(interfaceType).Elem()
+	value.Set(reflect.ValueOf(v))
+	return value, nil
+}
+
+func conversionError(obj object.Object, t reflect.Type) error {
+	return fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+}
+
+// sortedKeys はマップ v のキーを決まった順に並べて返す
+// 数と文字列と bool は値の順、interface のキーは型名の順に並べてから値の順にする
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareKeys(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		if a.IsNil() || b.IsNil() {
+			return cmp.Compare(boolInt(!a.IsNil()), boolInt(!b.IsNil()))
+		}
+		a, b = a.Elem(), b.Elem()
+		if a.Type() != b.Type() {
+			return cmp.Compare(a.Type().String(), b.Type().String())
+		}
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolInt(a.Bool()), boolInt(b.Bool()))
+	}
+	// 構造体などのキーは表示した文字列で並べる
+	return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package onu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-interpreter-practice/object"
+)
+
+type account struct {
+	Owner   string
+	Balance int
+	Tags    []string
+	secret  string
+}
+
+func (a *account) Deposit(amount int) int {
+	a.Balance += amount
+	return a.Balance
+}
+
+func (a *account) Withdraw(amount int) (int, error) {
+	if amount > a.Balance {
+		return a.Balance, errors.New("insufficient funds")
+	}
+	a.Balance -= amount
+	return a.Balance, nil
+}
+
+func TestToObject(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{42, "42"},
+		{uint8(7), "7"},
+		{1.5, "1.5"},
+		{"onu", "onu"},
+		{true, "true"},
+		{nil, "nil"},
+		{[]int{1, 2}, "[1, 2]"},
+		{map[string]int{"a": 1}, `{"a": 1}`},
+		// マップはキーの順に並べる
+		{map[string]int{"c": 3, "a": 1, "b": 2, "d": 4}, `{"a": 1, "b": 2, "c": 3, "d": 4}`},
+		{map[int]bool{10: true, -1: false, 3: true}, `{-1: false, 3: true, 10: true}`},
+		{map[interface{}]int{"b": 2, 1: 1, "a": 3, 0: 0}, `{0: 0, 1: 1, "a": 3, "b": 2}`},
+		{account{Owner: "x", Balance: 1}, `{"Owner": "x", "Balance": 1, "Tags": nil}`},
+	}
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%#v): %v", tt.input, err)
+			continue
+		}
+		if obj.String() != tt.expected {
+			t.Errorf("ToObject(%#v) = %s, want %s", tt.input, obj, tt.expected)
+		}
+	}
+
+	if _, err := ToObject(make(chan int)); err == nil {
+		t.Errorf("expected error for chan")
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	var n int8
+	if err := FromObject(object.NewInteger(300), &n); err == nil {
+		t.Errorf("expected overflow error")
+	}
+
+	var a account
+	hash := object.NewHash()
+	hash.Set(object.NewString("Owner"), object.NewString("onu"))
+	hash.Set(object.NewString("Tags"), object.NewArray([]object.Object{object.NewString("t")}))
+	if err := FromObject(hash, &a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Owner != "onu" || !reflect.DeepEqual(a.Tags, []string{"t"}) {
+		t.Errorf("got %+v", a)
+	}
+
+	// 文字列にすると同じになるキーは、どちらかを捨てずにエラーにする
+	colliding := object.NewHash()
+	colliding.Set(object.NewInteger(1), object.NewString("int"))
+	colliding.Set(object.NewString("1"), object.NewString("string"))
+	var m interface{}
+	if err := FromObject(colliding, &m); err == nil || err.Error() != `hash key "1" collides with another key as string "1"` {
+		t.Errorf("colliding keys: got %v, %v", m, err)
+	}
+
+	value := ToValue(object.NewArray([]object.Object{object.NewInteger(1), object.NewString("a"), hash}))
+	expected := []interface{}{1, "a", map[string]interface{}{"Owner": "onu", "Tags": []interface{}{"t"}}}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("ToValue = %#v, want %#v", value, expected)
+	}
+}
+
+func TestHostBindings(t *testing.T) {
+	var stdout bytes.Buffer
+	i := New()
+	i.SetStdout(&stdout)
+	acct := &account{Owner: "onu", Balance: 10}
+	if err := i.Bind("acct", acct); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Bind("upper", strings.ToUpper); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Bind("join", strings.Join); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := i.Run(context.Background(), "a.onu", `
+print(upper(acct.Owner))
+acct.Deposit(5)
+acct.Tags = ["a", "b"]
+print(join(acct.Tags, ","))
+print(acct.Balance)
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stdout.String(); got != "ONU\na,b\n15\n" {
+		t.Errorf("stdout = %q", got)
+	}
+	if acct.Balance != 15 || len(acct.Tags) != 2 {
+		t.Errorf("account not updated: %+v", acct)
+	}
+
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"acct.Withdraw(100)", "account.Withdraw: insufficient funds"},
+		{`upper(1)`, "upper: argument 1: cannot use INTEGER as string"},
+		{`upper()`, "wrong number of arguments: upper expects 1, got 0"},
+		{`acct.Balance = "x"`, "account.Balance: cannot use STRING as int"},
+		{"acct.secret", "undefined property secret"},
+	}
+	for _, tt := range tests {
+		_, err := i.Run(context.Background(), "b.onu", tt.input)
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%s: expected RuntimeError, got %v", tt.input, err)
+			continue
+		}
+		if runtimeErr.Err.Message != tt.message {
+			t.Errorf("%s: message = %q, want %q", tt.input, runtimeErr.Err.Message, tt.message)
+		}
+	}
+}
+
+func TestConvertCycles(t *testing.T) {
+	m := map[string]interface{}{}
+	m["self"] = m
+	s := []interface{}{nil}
+	s[0] = s
+	p := new(interface{})
+	*p = p
+	for _, v := range []interface{}{m, s, p} {
+		if _, err := ToObject(v); err == nil || !strings.Contains(err.Error(), "cyclic") {
+			t.Errorf("ToObject(%T): got %v, want cyclic error", v, err)
+		}
+	}
+
+	// 同じ値を何度参照していても循環していなければ変換できる
+	shared := []int{1}
+	obj, err := ToObject(map[string][]int{"a": shared, "b": shared})
+	if err != nil || obj.String() != `{"a": [1], "b": [1]}` {
+		t.Errorf("shared: got %v, %v", obj, err)
+	}
+
+	array := object.NewArray([]object.Object{object.NewInteger(1)})
+	array.Elements = append(array.Elements, array)
+	hash := object.NewHash()
+	hash.Set(object.NewString("self"), hash)
+	for _, obj := range []object.Object{array, hash} {
+		if got := ToValue(obj); got != interface{}(obj) {
+			t.Errorf("ToValue(%s) = %#v, want the object itself", obj.Type(), got)
+		}
+	}
+	var slice []interface{}
+	if err := FromObject(array, &slice); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("FromObject(array): got %v, want cyclic error", err)
+	}
+	var typed map[string]interface{}
+	if err := FromObject(hash, &typed); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("FromObject(hash): got %v, want cyclic error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package onu
+
+import (
+	"fmt"
+	"reflect"
+
+	"go-interpreter-practice/object"
+)
+
+// GoObject は Go の構造体へのポインタを包んだオブジェクト
+// スクリプトからは obj.Field でフィールドを読み書きし、obj.Method() でメソッドを呼べる
+// フィールドの変更は元の Go の値に反映される
+type GoObject struct {
+	value reflect.Value
+}
+
+// Value は包んでいる Go の値を返す
+func (g *GoObject) Value() interface{} {
+	return g.value.Interface()
+}
+
+func (g *GoObject) Type() object.ObjectType {
+	return object.HOST
+}
+
+func (g *GoObject) String() string {
+	return g.value.Type().String()
+}
+
+func (g *GoObject) IsTruthy() bool {
+	return true
+}
+
+// Get はエクスポートされたフィールドを優先して探し、なければメソッドを返す
+func (g *GoObject) Get(name string) (object.Object, bool) {
+	if field, ok := g.field(name); ok {
+		obj, err := valueToObject(field)
+		if err != nil {
+			return object.NewError("%s.%s: %v", g.value.Elem().Type().Name(), name, err), true
+		}
+		return obj, true
+	}
+	if method := g.value.MethodByName(name); method.IsValid() {
+		return wrapFunc(g.value.Elem().Type().Name()+"."+name, method), true
+	}
+	return nil, false
+}
+
+func (g *GoObject) Set(name string, value object.Object) *object.Error {
+	field, ok := g.field(name)
+	if !ok {
+		return object.NewError("undefined property %s", name)
+	}
+	converted, err := objectToValue(value, field.Type())
+	if err != nil {
+		return object.NewError("%s.%s: %v", g.value.Elem().Type().Name(), name, err)
+	}
+	field.Set(converted)
+	return nil
+}
+
+func (g *GoObject) field(name string) (reflect.Value, bool) {
+	structField, ok := g.value.Elem().Type().FieldByName(name)
+	if !ok || !structField.IsExported() {
+		return reflect.Value{}, false
+	}
+	return g.value.Elem().FieldByIndex(structField.Index), true
+}
+
+// WrapFunc は Go の関数をスクリプトから呼べる組み込み関数にする
+// 引数と戻り値は ToObject と FromObject の規則で変換する
+// 最後の戻り値が error のとき、nil でなければ実行時エラーになる
+func WrapFunc(name string, fn interface{}) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("%s is not a function: %T", name, fn)
+	}
+	return wrapFunc(name, v), nil
+}
+
+func wrapFunc(name string, fn reflect.Value) *object.Builtin {
+	t := fn.Type()
+	return &object.Builtin{Name: name, Fn: func(args ...object.Object) (result object.Object) {
+		// ホストの関数の panic でプロセスを落とさない
+		defer func() {
+			if r := recover(); r != nil {
+				result = object.NewError("%s: panic: %v", name, r)
+			}
+		}()
+
+		if err := checkFuncArity(name, t, len(args)); err != nil {
+			return err
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if t.IsVariadic() && i >= t.NumIn()-1 {
+				paramType = t.In(t.NumIn() - 1).Elem()
+			} else {
+				paramType = t.In(i)
+			}
+			converted, err := objectToValue(arg, paramType)
+			if err != nil {
+				return object.NewError("%s: argument %d: %v", name, i+1, err)
+			}
+			in[i] = converted
+		}
+		return funcResult(name, t, fn.Call(in))
+	}}
+}
+
+func checkFuncArity(name string, t reflect.Type, got int) *object.Error {
+	if t.IsVariadic() {
+		if got < t.NumIn()-1 {
+			return object.NewError("wrong number of arguments: %s expects at least %d, got %d", name, t.NumIn()-1, got)
+		}
+		return nil
+	}
+	if got != t.NumIn() {
+		return object.NewError("wrong number of arguments: %s expects %d, got %d", name, t.NumIn(), got)
+	}
+	return nil
+}
+
+// funcResult は Go の関数の戻り値を一つのオブジェクトにまとめる
+// 戻り値がなければ nil、一つならその値、複数なら配列にする
+func funcResult(name string, t reflect.Type, out []reflect.Value) object.Object {
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return object.NewError("%s: %v", name, err.Interface())
+		}
+		out = out[:len(out)-1]
+	}
+	results := make([]object.Object, len(out))
+	for i, v := range out {
+		obj, err := valueToObject(v)
+		if err != nil {
+			return object.NewError("%s: result %d: %v", name, i+1, err)
+		}
+		results[i] = obj
+	}
+	switch len(results) {
+	case 0:
+		return object.NewNil()
+	case 1:
+		return results[0]
+	}
+	return object.NewArray(results)
+}
@@ -0,0 +1,340 @@
+// Package onu は onu 言語を Go のプログラムに組み込むための API を提供する
+package onu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/compiler"
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/optimizer"
+	"go-interpreter-practice/parser"
+	"go-interpreter-practice/resolver"
+	"go-interpreter-practice/scanner"
+	"go-interpreter-practice/vm"
+)
+
+// Interpreter は一つのグローバル環境を持つ onu の実行器
+// Run を何度呼んでもグローバル変数は引き継がれる
+// 複数のゴルーチンから同時に使うことはできない
+type Interpreter struct {
+	evaluator *evaluator.Evaluator
+	vm        *vm.VM
+	backend   Backend
+	optimize  bool
+	globals   *object.Environment
+	limits    evaluator.Limits
+}
+
+// Backend はスクリプトを実行する方法
+type Backend int
+
+const (
+	TreeWalker Backend = iota // 構文木をそのまま評価する
+	BytecodeVM                // バイトコードにコンパイルして vm で実行する
+)
+
+// ParseBackend は "eval" か "vm" を Backend に変換する
+func ParseBackend(name string) (Backend, error) {
+	switch name {
+	case "eval":
+		return TreeWalker, nil
+	case "vm":
+		return BytecodeVM, nil
+	}
+	return 0, fmt.Errorf("unknown backend %q (want eval or vm)", name)
+}
+
+// CompileError は字句解析・構文解析・名前解決で見つかったエラー
+// この場合スクリプトは一行も実行されていない
+type CompileError struct {
+	Filename string
+	Errors   []error
+}
+
+func (e *CompileError) Error() string {
+	var out strings.Builder
+	for i, err := range e.Errors {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(fmt.Sprintf("%s: %v", e.Filename, err))
+	}
+	return out.String()
+}
+
+// RuntimeError は実行中に起きたエラー
+// Err にはスタックトレースが付いている
+// インタプリタ内部の panic も "internal error" の RuntimeError として返す
+type RuntimeError struct {
+	Filename string // Run で実行したスクリプトのファイル名(Call のときは空)
+	Function string // Call で呼び出した関数名(Run のときは空)
+	Err      *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Function != "" {
+		return fmt.Sprintf("call %s: %s", e.Function, e.Err.String())
+	}
+	return fmt.Sprintf("%s: %s", e.Filename, e.Err.String())
+}
+
+// New は標準入出力を使う Interpreter を作る
+func New() *Interpreter {
+	e := evaluator.NewEvaluator(os.Stdout)
+	globals := object.NewEnvironment()
+	return &Interpreter{
+		evaluator: e,
+		vm:        vm.New(e, globals),
+		globals:   globals,
+	}
+}
+
+// SetBackend は Run と Call で使う実行方法を設定する
+// 組み込み関数、グローバル変数、入出力はどちらの方法でも共有する
+// ただし片方で作った関数をもう片方で呼び出すことはできない
+func (i *Interpreter) SetBackend(backend Backend) {
+	i.backend = backend
+}
+
+// SetOptimize は Compile で optimizer を使うかどうかを設定する
+// 最適化したプログラムは、このプログラムが代入しないグローバル変数を定数として扱う
+// 後の Run やホストからそれらの変数に代入する場合は使わないこと
+func (i *Interpreter) SetOptimize(optimize bool) {
+	i.optimize = optimize
+}
+
+// SetStdin は input 関数の読み込み元を設定する
+func (i *Interpreter) SetStdin(r io.Reader) {
+	i.evaluator.SetInput(r)
+}
+
+// SetStdout は print 文と write, println 関数の出力先を設定する
+func (i *Interpreter) SetStdout(w io.Writer) {
+	i.evaluator.SetOutput(w)
+}
+
+// SetStderr は eprintln 関数の出力先を設定する
+func (i *Interpreter) SetStderr(w io.Writer) {
+	i.evaluator.SetErrorOutput(w)
+}
+
+// SetLimits は Run と Call ごとに適用する実行上限を設定する
+func (i *Interpreter) SetLimits(limits evaluator.Limits) {
+	i.limits = limits
+}
+
+// SetMaxCallDepth は関数呼び出しの深さの上限を設定する
+func (i *Interpreter) SetMaxCallDepth(depth int) {
+	i.evaluator.SetMaxCallDepth(depth)
+}
+
+// SetDebugger は構文木の評価器にデバッガのフックを設定する
+// バイトコードの vm で実行するときは呼ばれない
+func (i *Interpreter) SetDebugger(d evaluator.Debugger) {
+	i.evaluator.SetDebugger(d)
+}
+
+// SetHooks は構文木の評価器に、評価を観察するフックを設定する
+// バイトコードの vm で実行するときは呼ばれない
+func (i *Interpreter) SetHooks(h evaluator.Hooks) {
+	i.evaluator.SetHooks(h)
+}
+
+// RegisterBuiltin は Go の関数を組み込み関数としてスクリプトから呼べるようにする
+func (i *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	i.evaluator.RegisterBuiltin(name, fn)
+}
+
+// RegisterModule はモジュールを登録し、スクリプトから m.Name.member で使えるようにする
+func (i *Interpreter) RegisterModule(m *object.Module) {
+	i.evaluator.RegisterModule(m)
+}
+
+// SetGlobal はグローバル変数を設定する
+func (i *Interpreter) SetGlobal(name string, value object.Object) {
+	i.globals.Set(name, value)
+}
+
+// Bind は Go の値を ToObject で変換してグローバル変数に設定する
+// 関数は組み込み関数に、構造体へのポインタは GoObject になる
+func (i *Interpreter) Bind(name string, v interface{}) error {
+	var obj object.Object
+	if fn := reflect.ValueOf(v); fn.Kind() == reflect.Func && !fn.IsNil() {
+		// 関数はエラーメッセージに使う名前を付けて包む
+		obj = wrapFunc(name, fn)
+	} else {
+		converted, err := ToObject(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		obj = converted
+	}
+	i.globals.Set(name, obj)
+	return nil
+}
+
+// GetGlobal はグローバル変数を取得する
+func (i *Interpreter) GetGlobal(name string) (object.Object, bool) {
+	return i.globals.Get(name)
+}
+
+// Parse は src を構文解析する
+// エラーがあれば *CompileError を返す
+func Parse(filename, src string) (*ast.Program, error) {
+	p := parser.NewParser(scanner.NewScanner(src))
+	program, err := p.Parse()
+	if err != nil {
+		errs := p.GetErrors()
+		if len(errs) == 0 {
+			// 字句解析のエラー
+			errs = []error{err}
+		}
+		return nil, &CompileError{Filename: filename, Errors: errs}
+	}
+	return program, nil
+}
+
+// Compile は src を構文解析し、resolver で名前を検査する
+// 未宣言の変数などは実行する前に *CompileError として返す
+// グローバル変数と組み込み関数はこの Interpreter に登録されているものを使う
+// SetOptimize(true) なら検査した後で最適化する
+func (i *Interpreter) Compile(filename, src string) (*ast.Program, error) {
+	program, err := Parse(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.resolve(filename, program); err != nil {
+		return nil, err
+	}
+	if i.optimize {
+		// 書き換えた木の変数の位置を求め直す
+		optimizer.Optimize(program)
+		if err := i.resolve(filename, program); err != nil {
+			return nil, err
+		}
+	}
+	return program, nil
+}
+
+func (i *Interpreter) resolve(filename string, program *ast.Program) error {
+	r := resolver.NewResolver(i.isGlobal)
+	if err := r.Resolve(program); err != nil {
+		return &CompileError{Filename: filename, Errors: r.GetErrors()}
+	}
+	return nil
+}
+
+func (i *Interpreter) isGlobal(name string) bool {
+	if _, ok := i.globals.Get(name); ok {
+		return true
+	}
+	return i.evaluator.HasBuiltin(name)
+}
+
+// Run は src をグローバル環境で実行し、最後に評価した値を返す
+// 構文エラーは *CompileError、実行時エラーは *RuntimeError として返す
+func (i *Interpreter) Run(ctx context.Context, filename, src string) (result object.Object, err error) {
+	defer recoverError(&err, filename, "")
+	program, err := i.Compile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return i.RunProgram(ctx, filename, program)
+}
+
+// RunProgram は構文解析済みのプログラムをグローバル環境で実行する
+// Compile を通していないプログラムの名前の誤りは実行時エラーになる
+func (i *Interpreter) RunProgram(ctx context.Context, filename string, program *ast.Program) (result object.Object, err error) {
+	defer recoverError(&err, filename, "")
+	if i.backend == BytecodeVM {
+		bytecode, err := compileBytecode(filename, program)
+		if err != nil {
+			return nil, err
+		}
+		return i.RunBytecode(ctx, filename, bytecode)
+	}
+	return i.result(filename, i.evaluator.EvalContext(ctx, program, i.globals, i.limits))
+}
+
+// EvalIn は src を env のスコープで評価する
+// デバッガで一時停止している関数の変数を使って式を評価するためのもので、名前の検査はしない
+// 評価は呼び出し中の関数のスタックから切り離して行い、エラーの位置は src の中の行番号になる
+// SetBackend に関係なく構文木の評価器を使い、ctx と SetLimits の上限を守る
+func (i *Interpreter) EvalIn(ctx context.Context, filename, src string, env *object.Environment) (result object.Object, err error) {
+	defer recoverError(&err, filename, "")
+	program, err := Parse(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return i.result(filename, i.evaluator.EvalDetached(ctx, program, env, i.limits))
+}
+
+// CompileBytecode は src を構文解析してバイトコードにコンパイルする
+// 結果は SetBackend に関係なく RunBytecode で vm を使って実行する
+func (i *Interpreter) CompileBytecode(filename, src string) (*compiler.Bytecode, error) {
+	program, err := i.Compile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return compileBytecode(filename, program)
+}
+
+func compileBytecode(filename string, program *ast.Program) (*compiler.Bytecode, error) {
+	c := compiler.NewCompiler()
+	bytecode, err := c.Compile(program)
+	if err != nil {
+		return nil, &CompileError{Filename: filename, Errors: c.GetErrors()}
+	}
+	return bytecode, nil
+}
+
+// RunBytecode はコンパイル済みのプログラムを vm でグローバル環境で実行する
+func (i *Interpreter) RunBytecode(ctx context.Context, filename string, bytecode *compiler.Bytecode) (result object.Object, err error) {
+	defer recoverError(&err, filename, "")
+	return i.result(filename, i.vm.RunContext(ctx, bytecode, i.limits))
+}
+
+// recoverError は実行中の panic を *RuntimeError にして err に入れる
+// 組み込み関数やホストの関数のバグでホストのプログラムごと落ちないようにする
+func recoverError(err *error, filename, function string) {
+	if r := recover(); r != nil {
+		*err = &RuntimeError{Filename: filename, Function: function, Err: object.NewError("internal error: %v", r)}
+	}
+}
+
+func (i *Interpreter) result(filename string, result object.Object) (object.Object, error) {
+	if err, ok := result.(*object.Error); ok {
+		return nil, &RuntimeError{Filename: filename, Err: err}
+	}
+	return result, nil
+}
+
+// Call はグローバル変数 name に入っている関数を args で呼び出す
+func (i *Interpreter) Call(name string, args ...object.Object) (object.Object, error) {
+	return i.CallContext(context.Background(), name, args...)
+}
+
+// CallContext は ctx のキャンセルを確認しながら Call する
+func (i *Interpreter) CallContext(ctx context.Context, name string, args ...object.Object) (result object.Object, err error) {
+	defer recoverError(&err, "", name)
+	fn, ok := i.globals.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined function %s", name)
+	}
+	if i.backend == BytecodeVM {
+		result = i.vm.CallContext(ctx, fn, args, i.limits)
+	} else {
+		result = i.evaluator.CallContext(ctx, fn, args, i.limits)
+	}
+	if err, ok := result.(*object.Error); ok {
+		return nil, &RuntimeError{Function: name, Err: err}
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package onu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+)
+
+func TestInterpreterRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	i := New()
+	i.SetStdout(&stdout)
+	i.SetStderr(&stderr)
+	i.SetStdin(strings.NewReader("onu\n"))
+
+	_, err := i.Run(context.Background(), "a.onu", `
+var name = input()
+print("hello " + name)
+eprintln("warn")
+func add(a, b) { return a + b }
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stdout.String(); got != "hello onu\n" {
+		t.Errorf("stdout = %q", got)
+	}
+	if got := stderr.String(); got != "warn\n" {
+		t.Errorf("stderr = %q", got)
+	}
+
+	// グローバル変数は次の Run に引き継がれる
+	result, err := i.Run(context.Background(), "b.onu", "add(1, 2)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.String() != "3" {
+		t.Errorf("result = %s, want 3", result)
+	}
+}
+
+func TestInterpreterGlobalsAndCall(t *testing.T) {
+	i := New()
+	i.SetGlobal("base", object.NewInteger(10))
+	if _, err := i.Run(context.Background(), "a.onu", "func scale(n) { return base * n }"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := i.Call("scale", object.NewInteger(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.String() != "40" {
+		t.Errorf("result = %s, want 40", result)
+	}
+	if _, ok := i.GetGlobal("scale"); !ok {
+		t.Errorf("scale is not a global")
+	}
+	if _, err := i.Call("missing"); err == nil {
+		t.Errorf("expected error for undefined function")
+	}
+
+	_, err = i.Call("scale", object.NewString("x"))
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError, got %v", err)
+	}
+	if runtimeErr.Err.Line != 1 {
+		t.Errorf("line = %d, want 1", runtimeErr.Err.Line)
+	}
+	if runtimeErr.Function != "scale" || runtimeErr.Filename != "" {
+		t.Errorf("Function = %q, Filename = %q", runtimeErr.Function, runtimeErr.Filename)
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	i := New()
+	_, err := i.Run(context.Background(), "bad.onu", "var = 1")
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("expected CompileError, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "bad.onu: ") {
+		t.Errorf("error = %q", err.Error())
+	}
+
+	_, err = i.Run(context.Background(), "fail.onu", "1 / 0")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError, got %v", err)
+	}
+	if runtimeErr.Err.Message != "division by zero" {
+		t.Errorf("message = %q", runtimeErr.Err.Message)
+	}
+}
+
+// 組み込み関数の panic は RuntimeError になり、その後も同じ Interpreter を使える
+func TestInterpreterRecoversPanics(t *testing.T) {
+	for _, b := range backends {
+		i := b.new()
+		i.RegisterBuiltin("crash", func(args ...object.Object) object.Object {
+			panic("boom")
+		})
+		if _, err := i.Run(context.Background(), "a.onu", "func f(n) { return crash() + n }"); err != nil {
+			t.Fatalf("[%s] unexpected error: %v", b.name, err)
+		}
+
+		_, err := i.Run(context.Background(), "b.onu", "f(1)")
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "internal error: boom" {
+			t.Errorf("[%s] Run: got %v", b.name, err)
+		}
+		_, err = i.Call("f", object.NewInteger(1))
+		if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "internal error: boom" {
+			t.Errorf("[%s] Call: got %v", b.name, err)
+		}
+
+		result, err := i.Run(context.Background(), "c.onu", "func g(n) { return n * 2 }\ng(21)")
+		if err != nil || result.String() != "42" {
+			t.Errorf("[%s] after panic: got %v, %v", b.name, result, err)
+		}
+	}
+}
+
+// 実行中にホストの関数から Call しても、外側の実行の上限と ctx はそのまま効く
+func TestNestedCallKeepsLimits(t *testing.T) {
+	for _, b := range backends {
+		i := b.new()
+		i.SetLimits(evaluator.Limits{MaxSteps: 5000})
+		i.RegisterBuiltin("nested", func(args ...object.Object) object.Object {
+			result, err := i.Call(args[0].String())
+			if err != nil {
+				return object.NewError("%v", err)
+			}
+			return result
+		})
+		_, err := i.Run(context.Background(), "a.onu", `func one() { return 1 }
+nested("one")
+var n = 0
+for (x in range(1000000)) { n = n + 1 }`)
+		if err == nil || !strings.Contains(err.Error(), evaluator.StepLimitExceeded) {
+			t.Errorf("[%s] steps: got %v", b.name, err)
+		}
+
+		i.SetLimits(evaluator.Limits{})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = i.Run(ctx, "b.onu", `func spin() {
+  for (a in range(1000)) { for (b in range(1000)) { for (c in range(1000)) {} } }
+}
+nested("spin")`)
+		cancel()
+		if err == nil || !strings.Contains(err.Error(), evaluator.ExecutionCancelled) {
+			t.Errorf("[%s] cancel: got %v", b.name, err)
+		}
+	}
+}
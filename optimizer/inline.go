@@ -0,0 +1,179 @@
+package optimizer
+
+import (
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/token"
+)
+
+// inline は引数がすべてリテラルの呼び出しを、関数の本体を計算した結果で置き換える
+// 結果が定数にならない場合や、途中でエラーになる場合は nil を返して呼び出しを残す
+// (展開するとエラーのスタックトレースから関数のフレームが消えてしまうため)
+func (o *optimizer) inline(call *ast.CallExpression) ast.Expression {
+	callee, ok := (*call.Function).(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+	b := o.lookup(callee.Value)
+	if b == nil || b.kind != function {
+		return nil
+	}
+	args := make([]object.Object, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		v, ok := value(*arg)
+		if !ok {
+			return nil
+		}
+		args[i] = v
+	}
+	o.budget = maxInlineNodes
+	result, ok := o.call(b, args)
+	if !ok {
+		return nil
+	}
+	lit, _ := literal(result, call.Line())
+	return lit
+}
+
+// call は関数 b を args で呼んだ結果を計算する
+// 展開できるのは、可変長引数を持たず、本体が return 文か式文一つだけの再帰しない関数
+func (o *optimizer) call(b *binding, args []object.Object) (object.Object, bool) {
+	fn := b.fn
+	if o.inlining[fn] || fn.Rest != nil || len(fn.Parameters) != len(args) || len(fn.Body.Statements) != 1 {
+		return nil, false
+	}
+	var body ast.Expression
+	switch statement := fn.Body.Statements[0].(type) {
+	case *ast.ReturnStatement:
+		body = statement.ReturnValue
+	case *ast.ExpressionStatement:
+		body = statement.Expression
+	default:
+		return nil, false
+	}
+	params := make(map[string]object.Object, len(args))
+	for i, param := range fn.Parameters {
+		params[param.Value] = args[i]
+	}
+	o.inlining[fn] = true
+	defer delete(o.inlining, fn)
+	return o.evaluate(body, params, b.scope)
+}
+
+// evaluate は関数の本体の式を計算する
+// 使えるのはリテラル、引数、演算と、関数を宣言したスコープから見える関数宣言の呼び出しだけ
+func (o *optimizer) evaluate(expression ast.Expression, params map[string]object.Object, s *scope) (object.Object, bool) {
+	o.budget--
+	if o.budget < 0 {
+		return nil, false
+	}
+	if v, ok := value(expression); ok {
+		return v, true
+	}
+	switch node := expression.(type) {
+	case *ast.Identifier:
+		v, ok := params[node.Value]
+		return v, ok
+	case *ast.PrefixExpression:
+		right, ok := o.evaluate(node.Right, params, s)
+		if !ok {
+			return nil, false
+		}
+		return result(evaluator.PrefixOperation(node.Operator, right))
+	case *ast.InfixExpression:
+		left, ok := o.evaluate(node.Left, params, s)
+		if !ok {
+			return nil, false
+		}
+		right, ok := o.evaluate(node.Right, params, s)
+		if !ok {
+			return nil, false
+		}
+		return result(evaluator.InfixOperation(node.Operator, left, right))
+	case *ast.CallExpression:
+		callee, ok := (*node.Function).(*ast.Identifier)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := params[callee.Value]; ok {
+			return nil, false
+		}
+		b := hoistedFunction(s, callee.Value)
+		if b == nil {
+			return nil, false
+		}
+		args := make([]object.Object, len(node.Arguments))
+		for i, arg := range node.Arguments {
+			if args[i], ok = o.evaluate(*arg, params, s); !ok {
+				return nil, false
+			}
+		}
+		return o.call(b, args)
+	}
+	return nil, false
+}
+
+// hoistedFunction は s から見える name が、代入されない関数宣言ならそれを返す
+// 関数の本体はいつ呼ばれるか分からないので、常に初期化されている関数宣言だけを使う
+func hoistedFunction(s *scope, name string) *binding {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.bindings[name]; ok {
+			if b.hoisted && b.kind == function {
+				return b
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// result は演算の結果がリテラルで表せる値なら返す
+func result(obj object.Object) (object.Object, bool) {
+	if _, ok := literal(obj, 0); !ok {
+		return nil, false
+	}
+	return obj, true
+}
+
+// value はリテラルの値を返す
+func value(expression ast.Expression) (object.Object, bool) {
+	switch node := expression.(type) {
+	case *ast.IntegerLiteral:
+		return object.NewInteger(node.Value), true
+	case *ast.FloatLiteral:
+		return object.NewFloat(node.Value), true
+	case *ast.StringLiteral:
+		return object.NewString(node.Value), true
+	case *ast.Boolean:
+		return object.NewBoolean(node.Value), true
+	case *ast.NilLiteral:
+		return object.NewNil(), true
+	}
+	return nil, false
+}
+
+// literal は obj を line 行目のリテラルにする
+// エラーや配列などリテラルで表せない値なら false を返す
+func literal(obj object.Object, line int) (ast.Expression, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return &ast.IntegerLiteral{Token: newToken(token.INTEGER, obj, line), Value: obj.Value}, true
+	case *object.Float:
+		return &ast.FloatLiteral{Token: newToken(token.FLOAT, obj, line), Value: obj.Value}, true
+	case *object.String:
+		return &ast.StringLiteral{Token: newToken(token.STRING, obj, line), Value: obj.Value}, true
+	case *object.Boolean:
+		if obj.Value {
+			return &ast.Boolean{Token: newToken(token.TRUE, obj, line), Value: true}, true
+		}
+		return &ast.Boolean{Token: newToken(token.FALSE, obj, line), Value: false}, true
+	case *object.Nil:
+		return &ast.NilLiteral{Token: newToken(token.NIL, obj, line)}, true
+	}
+	return nil, false
+}
+
+func newToken(t token.TokenType, obj object.Object, line int) token.Token {
+	return token.Token{Type: t, RawToken: obj.String(), Line: line}
+}
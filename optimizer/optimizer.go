@@ -0,0 +1,352 @@
+// Package optimizer は評価の前に ast.Program を書き換えて、実行時の計算を減らす
+//
+// 次の最適化を行う
+//   - 定数だけからなる前置・中置式を畳み込む(2 * 60 * 60 => 7200)
+//   - 条件がリテラルの if 式から、実行されない分岐を取り除く
+//   - リテラルで初期化され、どこからも代入されない変数の参照を値で置き換える
+//   - 本体が式一つの小さな関数を引数がすべてリテラルで呼び出し、結果が定数になるときは、呼び出しをその値で置き換える
+//
+// 関数の本体を呼び出し元に展開すること(引数が定数でない呼び出しのインライン化)はしない
+// 展開すると、本体で起きたエラーのスタックトレースから関数のフレームが消えてしまうため
+//
+// 実行時エラーになる式(0 除算など)は畳み込まずに残すので、エラーメッセージとスタックトレースは変わらない
+// 書き換えた木は resolver でもう一度解析してから評価する
+//
+// このプログラムが代入しないグローバル変数は、ホストや後で実行するプログラムからも代入されないものとみなす
+package optimizer
+
+import (
+	"go-interpreter-practice/ast"
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+)
+
+// maxInlineNodes は一つの呼び出しの結果を計算するときにたどる式の数の上限
+// 再帰しない関数でも、呼び出しが入れ子になると計算する量が急に増えるので打ち切る
+const maxInlineNodes = 256
+
+type kind int
+
+const (
+	unknown  kind = iota // 値の分からない変数(引数、for のキー、クラス、代入される変数など)
+	constant             // リテラルで初期化された変数
+	function             // 関数で初期化された変数
+)
+
+type binding struct {
+	kind    kind
+	value   object.Object           // constant の値
+	fn      *ast.FunctionExpression // function の本体
+	scope   *scope                  // function を宣言したスコープ
+	defined bool                    // 宣言を実行し終えたか(巻き上げる関数宣言は最初から true)
+	hoisted bool                    // 巻き上げる関数宣言か
+}
+
+// scope は評価器が作る object.Environment 一つに対応する
+type scope struct {
+	parent   *scope
+	bindings map[string]*binding
+	function int // このスコープが属する関数の深さ(トップレベルは 0)
+}
+
+type optimizer struct {
+	scope    *scope
+	function int                              // 最適化している関数の深さ
+	hoisted  []bool                           // 関数の深さごとに、巻き上げる関数宣言の本体かどうか
+	assigned map[string]bool                  // プログラムのどこかで代入される名前
+	inlining map[*ast.FunctionExpression]bool // 結果を計算している最中の関数
+	budget   int
+}
+
+// Optimize は program をその場で最適化する
+// program は resolver でエラーがないことを確かめたものでなければならない
+func Optimize(program *ast.Program) {
+	o := &optimizer{
+		hoisted:  []bool{false},
+		assigned: assignedNames(program),
+		inlining: make(map[*ast.FunctionExpression]bool),
+	}
+	o.beginScope()
+	program.Statements = o.statements(program.Statements)
+	o.endScope()
+}
+
+// assignedNames は代入式の左辺に現れる変数名を集める
+// スコープを区別せず、同じ名前の変数はどれも代入されるものとして扱う
+func assignedNames(program *ast.Program) map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(program, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if name, ok := assign.Target.(*ast.Identifier); ok {
+				names[name.Value] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func (o *optimizer) beginScope() {
+	o.scope = &scope{parent: o.scope, bindings: make(map[string]*binding), function: o.function}
+}
+
+func (o *optimizer) endScope() {
+	o.scope = o.scope.parent
+}
+
+func (o *optimizer) declare(name string, b *binding) {
+	o.scope.bindings[name] = b
+}
+
+// lookup は評価器と同じく内側のスコープから name を探す
+// 参照する時点で値が決まっているとは言えない変数は nil を返す
+func (o *optimizer) lookup(name string) *binding {
+	for s := o.scope; s != nil; s = s.parent {
+		b, ok := s.bindings[name]
+		if !ok {
+			continue
+		}
+		if !b.defined {
+			// 関数の中から見える宣言前の変数は、呼び出される時点で宣言されているか分からない
+			if s.function < o.function {
+				return nil
+			}
+			// 同じ関数の中なら評価器と同じく飛ばして外側を探す
+			continue
+		}
+		// 巻き上げる関数は宣言より前で呼ばれることがあるので、外側の変数はまだ初期化されていないかもしれない
+		if !b.hoisted && o.insideHoisted(s.function) {
+			return nil
+		}
+		return b
+	}
+	return nil
+}
+
+// insideHoisted は深さ depth の関数より内側に、巻き上げる関数宣言の本体があるかを返す
+func (o *optimizer) insideHoisted(depth int) bool {
+	for i := depth + 1; i <= o.function; i++ {
+		if o.hoisted[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// statements は一つのスコープの文を最適化する
+func (o *optimizer) statements(statements []ast.Statement) []ast.Statement {
+	// 評価器と同じく、関数宣言を巻き上げてから var とクラスを宣言前の変数として記録する
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			b := &binding{defined: true, hoisted: true}
+			if !o.assigned[declaration.Name.Value] {
+				b.kind, b.fn, b.scope = function, declaration.Function, o.scope
+			}
+			o.declare(declaration.Name.Value, b)
+		}
+	}
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.VarStatement:
+			o.declare(statement.Name.Value, &binding{})
+		case *ast.ClassStatement:
+			o.declare(statement.Name.Value, &binding{})
+		}
+	}
+
+	optimized := make([]ast.Statement, 0, len(statements))
+	for i, statement := range statements {
+		statement = o.statement(statement)
+		// 最後の文はブロックや関数の値になるので、値を使わないときだけ書き換える
+		if expression, ok := statement.(*ast.ExpressionStatement); ok && i < len(statements)-1 {
+			if ie, ok := expression.Expression.(*ast.IfExpression); ok && isTrue(ie.Condition) && ie.Alternative == nil {
+				// 必ず実行する分岐は、スコープを保つためにブロックとして残す
+				statement = ie.Consequence
+			} else if _, ok := value(expression.Expression); ok {
+				// リテラルだけの式文は何もしない
+				continue
+			}
+		}
+		optimized = append(optimized, statement)
+	}
+	return optimized
+}
+
+func (o *optimizer) statement(statement ast.Statement) ast.Statement {
+	switch node := statement.(type) {
+	case *ast.ExpressionStatement:
+		node.Expression = o.expression(node.Expression)
+	case *ast.VarStatement:
+		node.Value = o.expression(node.Value)
+		if b, ok := o.scope.bindings[node.Name.Value]; ok {
+			if !o.assigned[node.Name.Value] {
+				if v, ok := value(node.Value); ok {
+					b.kind, b.value = constant, v
+				} else if fn, ok := node.Value.(*ast.FunctionExpression); ok {
+					b.kind, b.fn, b.scope = function, fn, o.scope
+				}
+			}
+			b.defined = true
+		}
+	case *ast.ReturnStatement:
+		node.ReturnValue = o.expression(node.ReturnValue)
+	case *ast.PrintStatement:
+		node.Value = o.expression(node.Value)
+	case *ast.BlockStatement:
+		o.block(node)
+	case *ast.FunctionStatement:
+		o.functionExpression(node.Function, true, false)
+	case *ast.ForStatement:
+		node.Iterable = o.expression(node.Iterable)
+		o.beginScope()
+		o.declare(node.Key.Value, &binding{defined: true})
+		node.Body.Statements = o.statements(node.Body.Statements)
+		o.endScope()
+	case *ast.ClassStatement:
+		for _, method := range node.Methods {
+			// メソッドは自分の名前のスコープの内側にある(resolver と同じ)
+			o.beginScope()
+			o.declare(method.Name.Value, &binding{defined: true})
+			o.functionExpression(method, false, false)
+			o.endScope()
+		}
+		if b, ok := o.scope.bindings[node.Name.Value]; ok {
+			b.defined = true
+		}
+	}
+	return statement
+}
+
+func (o *optimizer) block(block *ast.BlockStatement) *ast.BlockStatement {
+	if block == nil {
+		return nil
+	}
+	o.beginScope()
+	block.Statements = o.statements(block.Statements)
+	o.endScope()
+	return block
+}
+
+// functionExpression は関数の本体を最適化する
+// hoisted は巻き上げる関数宣言、expression は名前付き関数式なら true
+func (o *optimizer) functionExpression(fn *ast.FunctionExpression, hoisted, expression bool) {
+	if expression && fn.Name != nil {
+		o.beginScope()
+		o.declare(fn.Name.Value, &binding{defined: true})
+		defer o.endScope()
+	}
+	o.function++
+	o.hoisted = append(o.hoisted, hoisted)
+	defer func() {
+		o.function--
+		o.hoisted = o.hoisted[:len(o.hoisted)-1]
+	}()
+
+	// 引数と本体は同じスコープ
+	o.beginScope()
+	for i, param := range fn.Parameters {
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			fn.Defaults[i] = o.expression(fn.Defaults[i])
+		}
+		o.declare(param.Value, &binding{defined: true})
+	}
+	if fn.Rest != nil {
+		o.declare(fn.Rest.Value, &binding{defined: true})
+	}
+	fn.Body.Statements = o.statements(fn.Body.Statements)
+	o.endScope()
+}
+
+func (o *optimizer) expression(expression ast.Expression) ast.Expression {
+	switch node := expression.(type) {
+	case *ast.Identifier:
+		if b := o.lookup(node.Value); b != nil && b.kind == constant {
+			if lit, ok := literal(b.value, node.Line()); ok {
+				return lit
+			}
+		}
+	case *ast.PrefixExpression:
+		node.Right = o.expression(node.Right)
+		if right, ok := value(node.Right); ok {
+			if lit, ok := literal(evaluator.PrefixOperation(node.Operator, right), node.Line()); ok {
+				return lit
+			}
+		}
+	case *ast.InfixExpression:
+		node.Left = o.expression(node.Left)
+		node.Right = o.expression(node.Right)
+		if left, ok := value(node.Left); ok {
+			if right, ok := value(node.Right); ok {
+				if lit, ok := literal(evaluator.InfixOperation(node.Operator, left, right), node.Line()); ok {
+					return lit
+				}
+			}
+		}
+	case *ast.IfExpression:
+		return o.ifExpression(node)
+	case *ast.FunctionExpression:
+		o.functionExpression(node, false, true)
+	case *ast.CallExpression:
+		function := o.expression(*node.Function)
+		node.Function = &function
+		for i, arg := range node.Arguments {
+			optimized := o.expression(*arg)
+			node.Arguments[i] = &optimized
+		}
+		if inlined := o.inline(node); inlined != nil {
+			return inlined
+		}
+	case *ast.ArrayLiteral:
+		for i, element := range node.Elements {
+			node.Elements[i] = o.expression(element)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			pair.Key = o.expression(pair.Key)
+			pair.Value = o.expression(pair.Value)
+		}
+	case *ast.IndexExpression:
+		node.Left = o.expression(node.Left)
+		node.Index = o.expression(node.Index)
+	case *ast.AssignExpression:
+		node.Value = o.expression(node.Value)
+		// 代入先の変数は値で置き換えない
+		if _, ok := node.Target.(*ast.Identifier); !ok {
+			node.Target = o.expression(node.Target)
+		}
+	case *ast.GetExpression:
+		node.Object = o.expression(node.Object)
+	}
+	return expression
+}
+
+// ifExpression は条件がリテラルなら実行される分岐だけを残す
+func (o *optimizer) ifExpression(node *ast.IfExpression) ast.Expression {
+	node.Condition = o.expression(node.Condition)
+	condition, ok := value(node.Condition)
+	if !ok {
+		o.block(node.Consequence)
+		o.block(node.Alternative)
+		return node
+	}
+	branch := node.Consequence
+	if !condition.IsTruthy() {
+		branch = node.Alternative
+	}
+	if o.block(branch) == nil || len(branch.Statements) == 0 {
+		lit, _ := literal(object.NewNil(), node.Line())
+		return lit
+	}
+	// 式一つだけの分岐はその式にする
+	if statement, ok := branch.Statements[0].(*ast.ExpressionStatement); ok && len(branch.Statements) == 1 {
+		return statement.Expression
+	}
+	// 文を含む分岐はスコープを保つために if (true) { ... } として残す
+	always, _ := literal(object.NewBoolean(true), node.Line())
+	return &ast.IfExpression{Token: node.Token, Condition: always, Consequence: branch}
+}
+
+func isTrue(expression ast.Expression) bool {
+	b, ok := expression.(*ast.Boolean)
+	return ok && b.Value
+}
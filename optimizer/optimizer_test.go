@@ -0,0 +1,65 @@
+package optimizer
+
+import (
+	"go-interpreter-practice/parser"
+	"go-interpreter-practice/resolver"
+	"go-interpreter-practice/scanner"
+	"testing"
+)
+
+func optimize(t *testing.T, input string) string {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if err := resolver.NewResolver(nil).Resolve(program); err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	Optimize(program)
+	return program.String()
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"fold", "var day = 24 * 60 * 60", "var day = 86400"},
+		{"fold mixed", `print -(1 + 2.5) < 0 == !false`, "print true"},
+		{"fold strings", `print "a" + "b"`, `print "ab"`},
+		{"keep division by zero", "print 10 / (5 - 5)", "print (10 / 0)"},
+		{"keep overflow", "print 9223372036854775807 + 1", "print (9223372036854775807 + 1)"},
+		{"keep type errors", `print -"a"`, `print (-"a")`},
+		{"dead else", "var a = if (1 < 2) { \"yes\" } else { \"no\" }", `var a = "yes"`},
+		{"dead then", "var a = if (0) { 1 }", "var a = nil"},
+		{"keep scope of taken branch", "if (true) { var a = 1; print a }\nprint 2", "{\n  var a = 1\n  print 1\n}\nprint 2"},
+		{"keep value of last if", "func f() { if (true) { var a = 1; a } }", "func f() {\n  if (true) {\n    var a = 1\n    1\n  }\n}"},
+		{"drop unused literal", "if (false) { print 1 }\nprint 2", "print 2"},
+		{"unknown condition", "func f(x) { if (x) { 1 + 1 } }", "func f(x) {\n  if (x) {\n    2\n  }\n}"},
+		{"propagate", "var a = 2\nvar b = a * 3\nprint b", "var a = 2\nvar b = 6\nprint 6"},
+		{"assigned", "var a = 1\na = 2\nprint a", "var a = 1\na = 2\nprint a"},
+		{"shadowed by parameter", "var x = 1\nfunc f(x) { return x }", "var x = 1\nfunc f(x) {\n  return x\n}"},
+		{"shadowed in block", "var x = 1\nif (true) { var x = 2; print x }\nprint x", "var x = 1\n{\n  var x = 2\n  print 2\n}\nprint 1"},
+		{"closure after declaration", "func f() { var k = 1; return func() { k } }", "func f() {\n  var k = 1\n  return func() {\n    1\n  }\n}"},
+		// 巻き上げる関数は var より前に呼ばれることがある
+		{"hoisted function", "func f() { print g(); var k = 1; func g() { k } }", "func f() {\n  print g()\n  var k = 1\n  func g() {\n    k\n  }\n}"},
+		{"read before declaration", "var y = 1\nfunc f() { var before = y; var y = 2; before }", "var y = 1\nfunc f() {\n  var before = y\n  var y = 2\n  before\n}"},
+		{"inline", "func sq(x) { return x * x }\nprint sq(3)", "func sq(x) {\n  return (x * x)\n}\nprint 9"},
+		{"inline nested", "func sq(x) { x * x }\nfunc quad(x) { sq(sq(x)) }\nvar n = 2\nquad(n)", "func sq(x) {\n  (x * x)\n}\nfunc quad(x) {\n  sq(sq(x))\n}\nvar n = 2\n16"},
+		{"inline function value", "var double = func(x) { return x * 2 }\ndouble(4)", "var double = func(x) {\n  return (x * 2)\n}\n8"},
+		{"no inline with unknown args", "func sq(x) { x * x }\nfunc f(y) { sq(y) }", "func sq(x) {\n  (x * x)\n}\nfunc f(y) {\n  sq(y)\n}"},
+		{"no inline when it fails", "func inv(x) { 1 / x }\ninv(0)", "func inv(x) {\n  (1 / x)\n}\ninv(0)"},
+		{"no inline of recursion", "func f(n) { return f(n) }\nf(1)", "func f(n) {\n  return f(n)\n}\nf(1)"},
+		{"no inline with wrong arity", "func f(a, b = 1) { a }\nf(1)", "func f(a, b = 1) {\n  a\n}\nf(1)"},
+		{"no inline of free variables", "var k = 1\nfunc f(a) { a + k }\nf(1)", "var k = 1\nfunc f(a) {\n  (a + k)\n}\nf(1)"},
+		{"no inline of reassigned function", "func f() { 1 }\nf = nil\nf()", "func f() {\n  1\n}\nf = nil\nf()"},
+	}
+	for _, tt := range tests {
+		if got := optimize(t, tt.input); got != tt.expected {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -34,14 +34,20 @@ func NewParser(s *scanner.Scanner) *Parser {
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NIL, p.parseNil)
 	p.registerPrefix(token.LEFT_PAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUN, p.parseFuncExpression)
+	p.registerPrefix(token.LEFT_BRACE, p.parseHashLiteral)
+	p.registerPrefix(token.LEFT_BRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.THIS, p.parseThisExpression)
+	p.registerPrefix(token.SUPER, p.parseSuperExpression)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.STAR, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQUAL_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LESS, p.parseInfixExpression)
@@ -49,6 +55,9 @@ func NewParser(s *scanner.Scanner) *Parser {
 	p.registerInfix(token.GREATER, p.parseInfixExpression)
 	p.registerInfix(token.GREATER_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LEFT_PAREN, p.parseCallExpression)
+	p.registerInfix(token.LEFT_BRACKET, p.parseIndexExpression)
+	p.registerInfix(token.EQUAL, p.parseAssignExpression)
+	p.registerInfix(token.DOT, p.parseGetExpression)
 
 	return p
 }
@@ -139,11 +148,21 @@ func (p *Parser) runScanner() error {
 		return fmt.Errorf(msg.String())
 	}
 	p.tokens = p.scanner.Tokens()
+	// 末尾に足される改行と EOF には行番号がないので、最後の行のものとして扱う
+	// こうしないと入力の途中で終わったときのエラーが line 0 になる
+	last := 1
+	for i := range p.tokens {
+		if p.tokens[i].Line == 0 {
+			p.tokens[i].Line = last
+		}
+		last = p.tokens[i].Line
+	}
 	return nil
 }
 
 func (p *Parser) parseProgram() *ast.Program {
 	program := &ast.Program{}
+	p.skipMeaningless()
 	for !p.isAtEnd() {
 		stmt := p.parseStatement()
 		if stmt != nil {
@@ -160,29 +179,48 @@ func (p *Parser) parseStatement() *ast.Statement {
 		stmt = p.parseVarStatement()
 	case token.RETURN:
 		stmt = p.parseReturnStatement()
+	case token.FOR:
+		stmt = p.parseForStatement()
+	case token.CLASS:
+		stmt = p.parseClassStatement()
+	case token.PRINT:
+		stmt = p.parsePrintStatement()
+	case token.FUN:
+		if p.nextToken().Type == token.IDENTIFIER {
+			stmt = p.parseFunctionStatement()
+		} else {
+			stmt = p.parseExpressionStatement()
+		}
 	default:
 		stmt = p.parseExpressionStatement()
 	}
+	// 各文のパース関数は文の最後のトークンで止まるので、次の文の先頭まで進める
+	p.advance()
 	p.skipMeaningless()
 	return &stmt
 }
 
+// 文の区切り(改行とセミコロン)を読み飛ばす
 func (p *Parser) skipMeaningless() {
-	if p.nextToken().Type != token.LINE_BREAK && p.nextToken().Type != token.SEMICOLON && p.currentToken.Type != token.LINE_BREAK {
-		return
+	for p.currentToken.Type == token.LINE_BREAK || p.currentToken.Type == token.SEMICOLON {
+		if p.isAtEnd() {
+			return
+		}
+		p.advance()
 	}
-	for p.nextToken().Type == token.LINE_BREAK || p.nextToken().Type == token.SEMICOLON {
+}
+
+// 式の途中に現れる改行を読み飛ばす
+func (p *Parser) skipLineBreaks() {
+	for p.currentToken.Type == token.LINE_BREAK && !p.isAtEnd() {
 		p.advance()
 	}
-	p.advance()
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	expressionStatement := &ast.ExpressionStatement{}
 	expressionStatement.Token = p.currentToken
 	expressionStatement.Expression = p.parseExpression(LOWEST)
-	p.advance()
-	// p.parseEnd()
 	return expressionStatement
 }
 
@@ -206,15 +244,27 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	}
 	p.advance()
 	varStatement.Value = p.parseExpression(LOWEST)
-	p.advance()
 	return varStatement
 }
 
+// print expression
+func (p *Parser) parsePrintStatement() *ast.PrintStatement {
+	printStatement := &ast.PrintStatement{}
+	printStatement.Token = p.currentToken
+	p.advance()
+	printStatement.Value = p.parseExpression(LOWEST)
+	return printStatement
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	returnStatement := &ast.ReturnStatement{}
 	returnStatement.Token = p.currentToken
 	p.advance()
 	returnStatement.ReturnValue = p.parseExpression(LOWEST)
+	// return の直後の呼び出しは常に末尾位置にある
+	if call, ok := returnStatement.ReturnValue.(*ast.CallExpression); ok {
+		call.Tail = true
+	}
 	return returnStatement
 }
 
@@ -287,6 +337,10 @@ func (p *Parser) parseBoolean() ast.Expression {
 	}
 }
 
+func (p *Parser) parseNil() ast.Expression {
+	return &ast.NilLiteral{Token: p.currentToken}
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{
 		Token: p.currentToken,
@@ -327,17 +381,16 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		return nil
 	}
 	expression.Consequence = p.parseBlockStatement()
-	p.advance() // } の次のやつを消費　else句かもしれないし、そうじゃないかもしれない
 
-	if p.currentToken.Type == token.ELSE {
+	// } の次が else 句なら続けて読む
+	if p.nextToken().Type == token.ELSE {
+		p.advance() // else を消費
 		p.advance() // { を消費
 		if p.currentToken.Type != token.LEFT_BRACE {
 			p.addError(fmt.Sprintf("line %v; expected '{', but got %s", p.currentToken.Line, p.currentToken.RawToken))
 			return nil
 		}
 		expression.Alternative = p.parseBlockStatement()
-		p.skipMeaningless()
-		// p.advance() // } の次のやつを消費
 	}
 	return expression
 }
@@ -348,18 +401,16 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 	blockStatement.Statements = []ast.Statement{}
 	p.advance()
+	p.skipMeaningless() // 改行じゃなくなるまで改行を消費
 	for p.currentToken.Type != token.RIGHT_BRACE && p.currentToken.Type != token.EOF {
-		p.skipMeaningless() // 改行じゃなくなるまで改行を消費
-		// ボディが空の場合
-		if p.currentToken.Type == token.RIGHT_BRACE || p.currentToken.Type == token.EOF {
-			p.errors = append(p.errors, fmt.Errorf("line %v: empty if body", p.currentToken.Line))
-			break
-		}
 		stmt := p.parseStatement()
 		if stmt != nil {
 			blockStatement.Statements = append(blockStatement.Statements, *stmt)
 		}
 	}
+	if p.currentToken.Type != token.RIGHT_BRACE {
+		p.addError(fmt.Sprintf("line %v; expected '}', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+	}
 	return blockStatement
 }
 
@@ -372,46 +423,114 @@ func (p *Parser) parseFuncExpression() ast.Expression {
 		expression.Name = p.parseIdentifier().(*ast.Identifier)
 		p.advance()
 	}
+	if !p.parseFuncSignatureAndBody(expression) {
+		return nil
+	}
+	return expression
+}
 
+// func name(params) { body }
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	statement := &ast.FunctionStatement{Token: p.currentToken}
+	function, ok := p.parseFuncExpression().(*ast.FunctionExpression)
+	if !ok {
+		return nil
+	}
+	statement.Name = function.Name
+	statement.Function = function
+	return statement
+}
+
+// (params) { body } をパースする
+func (p *Parser) parseFuncSignatureAndBody(expression *ast.FunctionExpression) bool {
 	if p.currentToken.Type != token.LEFT_PAREN {
 		p.addError(fmt.Sprintf("line %v; expected '(', but got %s", p.currentToken.Line, p.currentToken.RawToken))
-		return nil
+		return false
 	}
-	expression.Parameters = p.parseFuncParameters()
+	p.parseFuncParameters(expression)
 	p.advance()
 	if p.currentToken.Type != token.LEFT_BRACE {
 		p.addError(fmt.Sprintf("line %v; expected '{', but got %s", p.currentToken.Line, p.currentToken.RawToken))
-		return nil
+		return false
 	}
 	expression.Body = p.parseBlockStatement()
-	return expression
+	markTailCalls(expression.Body)
+	return true
 }
 
-func (p *Parser) parseFuncParameters() []*ast.Identifier {
-	parameters := []*ast.Identifier{}
+// 関数本体の最後の式文が呼び出しなら、末尾位置の呼び出しとして印を付ける
+// 最後の式が if 式なら、それぞれの分岐の最後の式文も末尾位置になる
+func markTailCalls(block *ast.BlockStatement) {
+	if block == nil || len(block.Statements) == 0 {
+		return
+	}
+	statement, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return
+	}
+	switch expression := statement.Expression.(type) {
+	case *ast.CallExpression:
+		expression.Tail = true
+	case *ast.IfExpression:
+		markTailCalls(expression.Consequence)
+		markTailCalls(expression.Alternative)
+	}
+}
+
+// (a, b = 2, ...rest) をパースする
+// デフォルト値のある引数の後ろにはデフォルト値のない引数を置けず、可変長引数は最後にしか置けない
+func (p *Parser) parseFuncParameters(expression *ast.FunctionExpression) {
+	expression.Parameters = []*ast.Identifier{}
+	expression.Defaults = []ast.Expression{}
 	if p.nextToken().Type == token.RIGHT_PAREN {
 		p.advance()
-		return parameters
+		return
 	}
 	p.advance()
+	hasDefault := false
 	for p.currentToken.Type != token.RIGHT_PAREN && p.currentToken.Type != token.EOF {
-		p.skipMeaningless()
+		p.skipLineBreaks()
+		if expression.Rest != nil {
+			p.addError(fmt.Sprintf("line %v; rest parameter must be last", p.currentToken.Line))
+			return
+		}
+		isRest := p.currentToken.Type == token.ELLIPSIS
+		if isRest {
+			p.advance()
+		}
 		if p.currentToken.Type != token.IDENTIFIER {
 			p.addError(fmt.Sprintf("line %v; expected identifier, but got %s", p.currentToken.Line, p.currentToken.RawToken))
-			return nil
+			return
 		}
 		identifier := p.parseIdentifier().(*ast.Identifier)
-		parameters = append(parameters, identifier)
 		p.advance()
+
+		switch {
+		case isRest:
+			expression.Rest = identifier
+		case p.currentToken.Type == token.EQUAL:
+			p.advance()
+			expression.Parameters = append(expression.Parameters, identifier)
+			expression.Defaults = append(expression.Defaults, p.parseExpression(LOWEST))
+			hasDefault = true
+			p.advance()
+		case hasDefault:
+			p.addError(fmt.Sprintf("line %v; parameter %s without default follows parameter with default", p.currentToken.Line, identifier.Value))
+			return
+		default:
+			expression.Parameters = append(expression.Parameters, identifier)
+			expression.Defaults = append(expression.Defaults, nil)
+		}
+
+		p.skipLineBreaks()
 		if p.currentToken.Type != token.COMMA && p.currentToken.Type != token.RIGHT_PAREN {
 			p.addError(fmt.Sprintf("line %v; expected ',' or ')', but got %s", p.currentToken.Line, p.currentToken.RawToken))
-			return nil
+			return
 		}
 		if p.currentToken.Type == token.COMMA {
 			p.advance()
 		}
 	}
-	return parameters
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -435,10 +554,11 @@ func (p *Parser) parseCallArguments() []*ast.Expression {
 	}
 	p.advance()
 	for p.currentToken.Type != token.RIGHT_PAREN && p.currentToken.Type != token.EOF {
-		p.skipMeaningless()
+		p.skipLineBreaks()
 		arg := p.parseExpression(LOWEST)
 		args = append(args, &arg)
 		p.advance()
+		p.skipLineBreaks()
 		if p.currentToken.Type != token.COMMA && p.currentToken.Type != token.RIGHT_PAREN {
 			p.addError(fmt.Sprintf("line %v; expected ',' or ')', but got %s", p.currentToken.Line, p.currentToken.RawToken))
 			return nil
@@ -449,3 +569,228 @@ func (p *Parser) parseCallArguments() []*ast.Expression {
 	}
 	return args
 }
+
+// [1, "two", 3.0]
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{
+		Token:    p.currentToken,
+		Elements: []ast.Expression{},
+	}
+	p.advance() // [ を消費
+	p.skipLineBreaks()
+	for p.currentToken.Type != token.RIGHT_BRACKET {
+		if p.currentToken.Type == token.EOF {
+			p.addError(fmt.Sprintf("line %v; expected ']', but got EOF", p.currentToken.Line))
+			return nil
+		}
+		array.Elements = append(array.Elements, p.parseExpression(LOWEST))
+		p.advance() // , か ] を消費
+		p.skipLineBreaks()
+		if p.currentToken.Type != token.COMMA && p.currentToken.Type != token.RIGHT_BRACKET {
+			p.addError(fmt.Sprintf("line %v; expected ',' or ']', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+			return nil
+		}
+		if p.currentToken.Type == token.COMMA {
+			p.advance()
+			p.skipLineBreaks()
+		}
+	}
+	return array
+}
+
+// {"name": "x", 1: true}
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token: p.currentToken,
+		Pairs: []*ast.HashPair{},
+	}
+	p.advance() // { を消費
+	p.skipLineBreaks()
+	for p.currentToken.Type != token.RIGHT_BRACE {
+		if p.currentToken.Type == token.EOF {
+			p.addError(fmt.Sprintf("line %v; expected '}', but got EOF", p.currentToken.Line))
+			return nil
+		}
+		key := p.parseExpression(LOWEST)
+		p.advance() // : を消費
+		if p.currentToken.Type != token.COLON {
+			p.addError(fmt.Sprintf("line %v; expected ':', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+			return nil
+		}
+		p.advance()
+		p.skipLineBreaks()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, &ast.HashPair{Key: key, Value: value})
+
+		p.advance() // , か } を消費
+		p.skipLineBreaks()
+		if p.currentToken.Type != token.COMMA && p.currentToken.Type != token.RIGHT_BRACE {
+			p.addError(fmt.Sprintf("line %v; expected ',' or '}', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+			return nil
+		}
+		if p.currentToken.Type == token.COMMA {
+			p.advance()
+			p.skipLineBreaks()
+		}
+	}
+	return hash
+}
+
+// left[index]
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{
+		Token: p.currentToken,
+		Left:  left,
+	}
+	p.advance() // [ を消費
+	expression.Index = p.parseExpression(LOWEST)
+	p.advance() // ] を消費
+	if p.currentToken.Type != token.RIGHT_BRACKET {
+		p.addError(fmt.Sprintf("line %v; expected ']', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	return expression
+}
+
+// target = value
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:  p.currentToken,
+		Target: target,
+	}
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.GetExpression:
+	default:
+		p.addError(fmt.Sprintf("line %v; invalid assignment target", p.currentToken.Line))
+		return nil
+	}
+	p.advance()
+	// 右結合にするため、右辺は一番低い優先度でパースする
+	expression.Value = p.parseExpression(LOWEST)
+	return expression
+}
+
+// for (key in iterable) { ... }
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	statement := &ast.ForStatement{
+		Token: p.currentToken,
+	}
+	p.advance() // ( を消費
+	if p.currentToken.Type != token.LEFT_PAREN {
+		p.addError(fmt.Sprintf("line %v; expected '(', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	p.advance()
+	if p.currentToken.Type != token.IDENTIFIER {
+		p.addError(fmt.Sprintf("line %v; expected identifier, but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	statement.Key = p.parseIdentifier().(*ast.Identifier)
+	p.advance() // in を消費
+	if p.currentToken.Type != token.IN {
+		p.addError(fmt.Sprintf("line %v; expected 'in', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	p.advance()
+	statement.Iterable = p.parseExpression(LOWEST)
+	p.advance() // ) を消費
+	if p.currentToken.Type != token.RIGHT_PAREN {
+		p.addError(fmt.Sprintf("line %v; expected ')', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	p.advance() // { を消費
+	if p.currentToken.Type != token.LEFT_BRACE {
+		p.addError(fmt.Sprintf("line %v; expected '{', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	statement.Body = p.parseBlockStatement()
+	return statement
+}
+
+//	class Name < Superclass {
+//	  init(x) { this.x = x }
+//	  method() { ... }
+//	}
+func (p *Parser) parseClassStatement() *ast.ClassStatement {
+	statement := &ast.ClassStatement{
+		Token: p.currentToken,
+	}
+	p.advance()
+	if p.currentToken.Type != token.IDENTIFIER {
+		p.addError(fmt.Sprintf("line %v; expected class name, but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	statement.Name = p.parseIdentifier().(*ast.Identifier)
+	p.advance()
+
+	if p.currentToken.Type == token.LESS {
+		p.advance()
+		if p.currentToken.Type != token.IDENTIFIER {
+			p.addError(fmt.Sprintf("line %v; expected superclass name, but got %s", p.currentToken.Line, p.currentToken.RawToken))
+			return nil
+		}
+		statement.Superclass = p.parseIdentifier().(*ast.Identifier)
+		p.advance()
+	}
+
+	if p.currentToken.Type != token.LEFT_BRACE {
+		p.addError(fmt.Sprintf("line %v; expected '{', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	p.advance()
+	p.skipMeaningless()
+	for p.currentToken.Type != token.RIGHT_BRACE {
+		if p.currentToken.Type != token.IDENTIFIER {
+			p.addError(fmt.Sprintf("line %v; expected method name, but got %s", p.currentToken.Line, p.currentToken.RawToken))
+			return nil
+		}
+		method := &ast.FunctionExpression{
+			Token: p.currentToken,
+			Name:  p.parseIdentifier().(*ast.Identifier),
+		}
+		p.advance()
+		if !p.parseFuncSignatureAndBody(method) {
+			return nil
+		}
+		statement.Methods = append(statement.Methods, method)
+		p.advance() // } を消費
+		p.skipMeaningless()
+	}
+	return statement
+}
+
+// object.name
+func (p *Parser) parseGetExpression(object ast.Expression) ast.Expression {
+	expression := &ast.GetExpression{
+		Token:  p.currentToken,
+		Object: object,
+	}
+	p.advance()
+	if p.currentToken.Type != token.IDENTIFIER {
+		p.addError(fmt.Sprintf("line %v; expected property name after '.', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	expression.Name = p.parseIdentifier().(*ast.Identifier)
+	return expression
+}
+
+func (p *Parser) parseThisExpression() ast.Expression {
+	return &ast.ThisExpression{Token: p.currentToken}
+}
+
+// super.method
+func (p *Parser) parseSuperExpression() ast.Expression {
+	expression := &ast.SuperExpression{Token: p.currentToken}
+	p.advance()
+	if p.currentToken.Type != token.DOT {
+		p.addError(fmt.Sprintf("line %v; expected '.' after 'super', but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	p.advance()
+	if p.currentToken.Type != token.IDENTIFIER {
+		p.addError(fmt.Sprintf("line %v; expected superclass method name, but got %s", p.currentToken.Line, p.currentToken.RawToken))
+		return nil
+	}
+	expression.Method = p.parseIdentifier().(*ast.Identifier)
+	return expression
+}
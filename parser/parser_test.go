@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"go-interpreter-practice/scanner"
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		// 文の区切り
+		{input: "var a = 1; var b = 2", want: "var a = 1\nvar b = 2"},
+		{input: "var a = 1\nvar b = 2", want: "var a = 1\nvar b = 2"},
+		{input: "var a = 1;;\n;\nvar b = 2;", want: "var a = 1\nvar b = 2"},
+		{input: "\n\n// comment\nvar a = 1\n\n", want: "var a = 1"},
+		{input: "1 + 2 * 3\n-a * b", want: "(1 + (2 * 3))\n((-a) * b)"},
+		// 括弧の中の改行
+		{input: "f(1,\n  2\n)", want: "f(1, 2)"},
+		{input: "func f(a,\n  b\n) {\n  return a\n}", want: "func f(a, b) {\n  return a\n}"},
+		// if と else
+		{input: "if (a) {\n\n  1\n\n  2\n} else {\n  3\n}", want: "if (a) {\n  1\n  2\n} else {\n  3\n}"},
+		{input: "if (a) { 1 }\nb", want: "if (a) {\n  1\n}\nb"},
+		{input: "if (a) { 1 }; if (b) { 2 } else { 3 }", want: "if (a) {\n  1\n}\nif (b) {\n  2\n} else {\n  3\n}"},
+		{input: "if (a) {}", want: "if (a) {}"},
+		// ハッシュ
+		{input: `{"name": "x", 1: true}`, want: `{"name": "x", 1: true}`},
+		{input: "{\n  \"a\": 1,\n  \"b\":\n    2,\n}", want: `{"a": 1, "b": 2}`},
+		{input: "{}", want: "{}"},
+		{input: "var h = {\"a\": {\"b\": 1}}\nh[\"a\"][\"b\"] = 2", want: "var h = {\"a\": {\"b\": 1}}\n((h[\"a\"])[\"b\"]) = 2"},
+		{input: "a = b = 3", want: "a = b = 3"},
+		// for-in
+		{input: "for (x in [1, 2]) {\n  print x\n}", want: "for (x in [1, 2]) {\n  print x\n}"},
+		{input: "for (k in {\"a\": 1}) { print k }\nk", want: "for (k in {\"a\": 1}) {\n  print k\n}\nk"},
+		{input: "for (x in xs) {}", want: "for (x in xs) {}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			program, err := NewParser(scanner.NewScanner(tc.input)).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if program.String() != tc.want {
+				t.Errorf("expected %q, but got %q", tc.want, program.String())
+			}
+		})
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{input: "var = 1", want: "line 1; expected identifier, but got ="},
+		{input: "var a 1", want: "line 1; expected '=', but got 1"},
+		{input: "if (a) {\n  1\n", want: "line 2; expected '}', but got "},
+		{input: `{"a" 1}`, want: "line 1; expected ':', but got 1"},
+		{input: `{"a": 1 "b": 2}`, want: "line 1; expected ',' or '}', but got \"b\""},
+		{input: `{"a": 1,`, want: "line 1; expected '}', but got EOF"},
+		{input: "for x in xs {}", want: "line 1; expected '(', but got x"},
+		{input: "for (x xs) {}", want: "line 1; expected 'in', but got xs"},
+		{input: "1 = 2", want: "line 1; invalid assignment target"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("入力: %s", tc.input), func(t *testing.T) {
+			_, err := NewParser(scanner.NewScanner(tc.input)).Parse()
+			if err == nil {
+				t.Fatalf("expected %v, but got no error", tc.want)
+			}
+			// 最初のエラーに続いて、読み直しで出たエラーが連結されることがある
+			if got := err.Error(); !strings.HasPrefix(got, tc.want) {
+				t.Errorf("expected %q, but got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -7,15 +7,18 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // =
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
-	PRODUCT     // *
+	PRODUCT     // * / %
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
 var priorityMap = map[token.TokenType]int{
+	token.EQUAL:         ASSIGN,
 	token.EQUAL_EQUAL:   EQUALS,
 	token.NOT_EQUAL:     EQUALS,
 	token.LESS:          LESSGREATER,
@@ -26,5 +29,8 @@ var priorityMap = map[token.TokenType]int{
 	token.MINUS:         SUM,
 	token.SLASH:         PRODUCT,
 	token.STAR:          PRODUCT,
+	token.PERCENT:       PRODUCT,
 	token.LEFT_PAREN:    CALL,
+	token.LEFT_BRACKET:  INDEX,
+	token.DOT:           INDEX,
 }
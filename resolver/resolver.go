@@ -0,0 +1,337 @@
+// Package resolver は構文解析と評価の間で ast.Program を走査し、
+// 実行しなくても分かるスコープの誤りを報告する
+//
+// あわせて、各識別子がどのスコープの何番目の変数を指すかを ast.Identifier に書き込む
+// 評価器はそれを使って名前を探さずに変数を読み書きする
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"go-interpreter-practice/ast"
+	"strings"
+)
+
+// 変数の状態
+type state int
+
+const (
+	pending  state = iota // 同じブロックの後ろで宣言される
+	defining              // 初期化式を評価している最中
+	defined
+)
+
+type variable struct {
+	state state
+	line  int // 宣言した行
+	slot  int // スコープの中での位置
+}
+
+// scope は評価器が作る object.Environment 一つに対応する
+// names は評価器が変数を宣言する順に並ぶ
+type scope struct {
+	variables map[string]*variable
+	names     []string
+	function  int // このスコープが属する関数の深さ(トップレベルは 0)
+}
+
+type classKind int
+
+const (
+	noClass classKind = iota
+	plainClass
+	subclass
+)
+
+type Resolver struct {
+	scopes   []*scope
+	errors   []error
+	function int // 解析中の関数の深さ
+	class    classKind
+	isGlobal func(name string) bool
+}
+
+// NewResolver は Resolver を作る
+// isGlobal はプログラムの外で定義された名前(組み込み関数やホストが設定した変数)を判定する
+func NewResolver(isGlobal func(name string) bool) *Resolver {
+	if isGlobal == nil {
+		isGlobal = func(string) bool { return false }
+	}
+	return &Resolver{isGlobal: isGlobal}
+}
+
+func (r *Resolver) GetErrors() []error {
+	return r.errors
+}
+
+func (r *Resolver) addError(line int, format string, a ...interface{}) {
+	r.errors = append(r.errors, fmt.Errorf("line %v; %s", line, fmt.Sprintf(format, a...)))
+}
+
+// Resolve はプログラム全体を解析し、見つかったエラーをまとめて返す
+func (r *Resolver) Resolve(program *ast.Program) error {
+	r.beginScope()
+	r.resolveStatements(program.Statements)
+	r.endScope()
+
+	if len(r.errors) > 0 {
+		messages := make([]string, len(r.errors))
+		for i, err := range r.errors {
+			messages[i] = err.Error()
+		}
+		return errors.New(strings.Join(messages, "\n"))
+	}
+	return nil
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, &scope{variables: make(map[string]*variable), function: r.function})
+}
+
+// endScope はスコープを閉じ、そのスコープのローカル変数名を宣言順に返す
+func (r *Resolver) endScope() []string {
+	s := r.currentScope()
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	return s.names
+}
+
+func (r *Resolver) currentScope() *scope {
+	return r.scopes[len(r.scopes)-1]
+}
+
+func (r *Resolver) declare(name *ast.Identifier, s state) {
+	scope := r.currentScope()
+	if v, ok := scope.variables[name.Value]; ok {
+		r.addError(name.Line(), "%s is already declared in this scope (line %d)", name.Value, v.line)
+		return
+	}
+	r.define(name.Value, s, name.Line())
+}
+
+// define は宣言の検査をせずに変数を追加する(this や super など)
+func (r *Resolver) define(name string, s state, line int) {
+	scope := r.currentScope()
+	scope.variables[name] = &variable{state: s, line: line, slot: len(scope.names)}
+	scope.names = append(scope.names, name)
+}
+
+func (r *Resolver) setState(name *ast.Identifier, s state) {
+	if v, ok := r.currentScope().variables[name.Value]; ok && v.line == name.Line() {
+		v.state = s
+	}
+}
+
+// 評価器と同じ順で文を解析する
+// 関数宣言は巻き上げ、var とクラスは後ろで宣言されることだけを先に記録する
+func (r *Resolver) resolveStatements(statements []ast.Statement) {
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			r.declare(declaration.Name, defined)
+		}
+	}
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.VarStatement:
+			r.declare(statement.Name, pending)
+		case *ast.ClassStatement:
+			r.declare(statement.Name, pending)
+		}
+	}
+	for _, statement := range statements {
+		r.resolveStatement(statement)
+	}
+}
+
+func (r *Resolver) resolveStatement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case *ast.ExpressionStatement:
+		r.resolveExpression(node.Expression)
+	case *ast.VarStatement:
+		r.setState(node.Name, defining)
+		r.resolveExpression(node.Value)
+		r.setState(node.Name, defined)
+	case *ast.ReturnStatement:
+		if r.function == 0 {
+			r.addError(node.Line(), "return outside of a function")
+		}
+		r.resolveExpression(node.ReturnValue)
+	case *ast.BlockStatement:
+		r.resolveBlock(node)
+	case *ast.PrintStatement:
+		r.resolveExpression(node.Value)
+	case *ast.FunctionStatement:
+		r.resolveFunction(node.Function, false)
+	case *ast.ForStatement:
+		r.resolveExpression(node.Iterable)
+		r.beginScope()
+		r.declare(node.Key, defined)
+		r.resolveStatements(node.Body.Statements)
+		node.Locals = r.endScope()
+	case *ast.ClassStatement:
+		r.resolveClass(node)
+	}
+}
+
+func (r *Resolver) resolveBlock(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	r.beginScope()
+	r.resolveStatements(block.Statements)
+	block.Locals = r.endScope()
+}
+
+// 名前付きの関数式は自分の名前だけを持つスコープを作る(newFunction と同じ)
+// 関数宣言は巻き上げで名前が束縛されるので作らない
+func (r *Resolver) resolveFunction(fn *ast.FunctionExpression, expression bool) {
+	if expression && fn.Name != nil {
+		r.beginScope()
+		r.declare(fn.Name, defined)
+		defer r.endScope()
+	}
+	r.function++
+	defer func() { r.function-- }()
+
+	// 引数と本体は同じスコープ
+	// デフォルト値からはそれより前の引数だけが見える
+	r.beginScope()
+	for i, param := range fn.Parameters {
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			r.resolveExpression(fn.Defaults[i])
+		}
+		r.declare(param, defined)
+	}
+	if fn.Rest != nil {
+		r.declare(fn.Rest, defined)
+	}
+	r.resolveStatements(fn.Body.Statements)
+	fn.Locals = r.endScope()
+}
+
+func (r *Resolver) resolveClass(node *ast.ClassStatement) {
+	enclosing := r.class
+	r.class = plainClass
+	defer func() { r.class = enclosing }()
+
+	if node.Superclass != nil {
+		if node.Superclass.Value == node.Name.Value {
+			r.addError(node.Superclass.Line(), "a class can't inherit from itself")
+		} else {
+			r.resolveIdentifier(node.Superclass)
+		}
+		r.class = subclass
+		// メソッドからは super が見える
+		r.beginScope()
+		r.define("super", defined, node.Line())
+	}
+	for _, method := range node.Methods {
+		r.resolveMethod(method)
+	}
+	if node.Superclass != nil {
+		r.endScope()
+	}
+	r.setState(node.Name, defined)
+}
+
+// メソッドは名前のスコープの内側に this のスコープを持つ(Function.Bind と同じ)
+func (r *Resolver) resolveMethod(method *ast.FunctionExpression) {
+	r.beginScope()
+	r.declare(method.Name, defined)
+	r.beginScope()
+	r.define("this", defined, method.Line())
+
+	r.resolveFunction(method, false)
+
+	r.endScope()
+	r.endScope()
+}
+
+func (r *Resolver) resolveExpression(expression ast.Expression) {
+	switch node := expression.(type) {
+	case nil:
+	case *ast.Identifier:
+		r.resolveIdentifier(node)
+	case *ast.PrefixExpression:
+		r.resolveExpression(node.Right)
+	case *ast.InfixExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+	case *ast.IfExpression:
+		r.resolveExpression(node.Condition)
+		r.resolveBlock(node.Consequence)
+		r.resolveBlock(node.Alternative)
+	case *ast.FunctionExpression:
+		r.resolveFunction(node, true)
+	case *ast.CallExpression:
+		r.resolveExpression(*node.Function)
+		for _, arg := range node.Arguments {
+			r.resolveExpression(*arg)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			r.resolveExpression(pair.Key)
+			r.resolveExpression(pair.Value)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			r.resolveExpression(element)
+		}
+	case *ast.IndexExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Index)
+	case *ast.AssignExpression:
+		r.resolveExpression(node.Value)
+		r.resolveExpression(node.Target)
+	case *ast.GetExpression:
+		r.resolveExpression(node.Object)
+	case *ast.ThisExpression:
+		if r.class == noClass {
+			r.addError(node.Line(), "'this' used outside of a class")
+		}
+	case *ast.SuperExpression:
+		switch r.class {
+		case noClass:
+			r.addError(node.Line(), "'super' used outside of a class")
+		case plainClass:
+			r.addError(node.Line(), "'super' used in a class with no superclass")
+		}
+	}
+}
+
+// resolveIdentifier は評価器と同じく内側のスコープから名前を探す
+func (r *Resolver) resolveIdentifier(name *ast.Identifier) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		v, ok := r.scopes[i].variables[name.Value]
+		if !ok {
+			continue
+		}
+		// 関数の中からの参照は、呼び出される時点で宣言済みになっているとみなす
+		crossesFunction := r.scopes[i].function < r.function
+		switch {
+		case v.state == defined, crossesFunction:
+			r.annotate(name, i, v.slot)
+			return
+		case v.state == defining:
+			r.addError(name.Line(), "can't read %s in its own initializer", name.Value)
+			return
+		}
+		// まだ宣言されていない変数は飛ばし、外側のスコープを探す
+	}
+	if !r.isGlobal(name.Value) {
+		r.addError(name.Line(), "undefined identifier %s", name.Value)
+		return
+	}
+	// 組み込み関数もグローバルスコープから探し始める
+	r.annotate(name, 0, -1)
+}
+
+// annotate は name が i 番目のスコープの slot 番目を指すことを記録する
+// トップレベルのスコープは名前で探すグローバルスコープになる
+func (r *Resolver) annotate(name *ast.Identifier, i, slot int) {
+	if i == 0 {
+		slot = -1
+	}
+	name.Resolved = true
+	name.Depth = len(r.scopes) - 1 - i
+	name.Slot = slot
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"go-interpreter-practice/parser"
+	"go-interpreter-practice/scanner"
+	"reflect"
+	"testing"
+)
+
+func resolve(t *testing.T, input string) []string {
+	t.Helper()
+	p := parser.NewParser(scanner.NewScanner(input))
+	program, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := NewResolver(func(name string) bool { return name == "len" })
+	r.Resolve(program)
+	var messages []string
+	for _, err := range r.GetErrors() {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func TestResolveValidPrograms(t *testing.T) {
+	tests := []string{
+		// 宣言より前で呼ばれる関数宣言と相互再帰
+		`isEven(4)
+func isEven(n) { if (n == 0) { return true } return isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false } return isEven(n - 1) }`,
+		// 関数の中からは後で宣言されるグローバル変数を参照できる
+		`func get() { return config }
+var config = 1
+get()`,
+		// 内側のブロックで外側の名前を隠せる
+		`var x = 1
+if (true) { var x = 2 }`,
+		// 名前付き関数式は自分の名前で再帰できる
+		`var f = func fact(n) { if (n == 0) { return 1 } return n * fact(n - 1) }`,
+		// var に入れた無名関数も自分を呼べる
+		`var loop = func(n) { if (n > 0) { loop(n - 1) } }`,
+		`func f(a, b = a, ...rest) { return len(rest) + b }`,
+		`for (k in [1, 2]) { var v = k }`,
+		`class A { init(x) { this.x = x } get() { return this.x } }
+class B < A { get() { return super.get() + 1 } make() { return B(1) } }`,
+	}
+	for _, input := range tests {
+		if errs := resolve(t, input); len(errs) > 0 {
+			t.Errorf("unexpected errors for %q: %v", input, errs)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"y + 1", []string{"line 1; undefined identifier y"}},
+		{"func f() { return missing }", []string{"line 1; undefined identifier missing"}},
+		{"print(x)\nvar x = 1", []string{"line 1; undefined identifier x"}},
+		{"var a = a", []string{"line 1; can't read a in its own initializer"}},
+		{"var a = 1\nif (true) { var a = a + 1 }", []string{"line 2; can't read a in its own initializer"}},
+		{"var a = 1\nvar a = 2", []string{"line 2; a is already declared in this scope (line 1)"}},
+		{"func f() {}\nvar f = 1", []string{"line 2; f is already declared in this scope (line 1)"}},
+		{"func f(a, a) {}", []string{"line 1; a is already declared in this scope (line 1)"}},
+		{"func f(a) { var a = 1 }", []string{"line 1; a is already declared in this scope (line 1)"}},
+		{"return 1", []string{"line 1; return outside of a function"}},
+		{"this", []string{"line 1; 'this' used outside of a class"}},
+		{"func f() { return this }", []string{"line 1; 'this' used outside of a class"}},
+		{"super.x", []string{"line 1; 'super' used outside of a class"}},
+		{"class A { f() { return super.f() } }", []string{"line 1; 'super' used in a class with no superclass"}},
+		{"class A < A {}", []string{"line 1; a class can't inherit from itself"}},
+		// 実行されない枝のエラーも見つかる
+		{"if (false) { undefinedFunction() }", []string{"line 1; undefined identifier undefinedFunction"}},
+	}
+	for _, tt := range tests {
+		errs := resolve(t, tt.input)
+		if !reflect.DeepEqual(errs, tt.expected) {
+			t.Errorf("%q: got %q, want %q", tt.input, errs, tt.expected)
+		}
+	}
+}
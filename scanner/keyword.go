@@ -10,6 +10,7 @@ var keywords = map[string]token.TokenType{
 	"for":    token.FOR,
 	"func":   token.FUN,
 	"if":     token.IF,
+	"in":     token.IN,
 	"nil":    token.NIL,
 	"or":     token.OR,
 	"print":  token.PRINT,
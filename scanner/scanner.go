@@ -46,7 +46,8 @@ func (s *Scanner) Tokens() []token.Token {
 }
 
 func (s *Scanner) ScanTokens() {
-	for {
+	// 空の入力には読む文字がない
+	for s.source != "" {
 		s.start = s.currentAt
 		s.scanToken()
 		if s.isAtEnd() {
@@ -80,12 +81,28 @@ func (s *Scanner) scanToken() {
 	case '}':
 		t := s.createToken(token.RIGHT_BRACE)
 		s.addToken(t)
+	case '[':
+		t := s.createToken(token.LEFT_BRACKET)
+		s.addToken(t)
+	case ']':
+		t := s.createToken(token.RIGHT_BRACKET)
+		s.addToken(t)
 	case ',':
 		t := s.createToken(token.COMMA)
 		s.addToken(t)
-	case '.':
-		t := s.createToken(token.DOT)
+	case ':':
+		t := s.createToken(token.COLON)
 		s.addToken(t)
+	case '.':
+		if s.peekNext() == '.' && s.peekNextNext() == '.' {
+			s.advance()
+			s.advance()
+			t := s.createToken(token.ELLIPSIS)
+			s.addToken(t)
+		} else {
+			t := s.createToken(token.DOT)
+			s.addToken(t)
+		}
 	case '-':
 		t := s.createToken(token.MINUS)
 		s.addToken(t)
@@ -98,6 +115,9 @@ func (s *Scanner) scanToken() {
 	case '*':
 		t := s.createToken(token.STAR)
 		s.addToken(t)
+	case '%':
+		t := s.createToken(token.PERCENT)
+		s.addToken(t)
 	case '!':
 		if s.peekNext() == '=' {
 			s.advance()
@@ -179,7 +199,7 @@ func (s *Scanner) peekNext() rune {
 }
 
 func (s *Scanner) peekNextNext() rune {
-	if s.currentAt+1 >= len([]rune(s.source)) {
+	if s.currentAt+2 >= len([]rune(s.source)) {
 		return '\x00'
 	}
 	return []rune(s.source)[s.currentAt+2]
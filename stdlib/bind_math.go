@@ -0,0 +1,852 @@
+// Code generated by "onu bind math"; DO NOT EDIT.
+
+package stdlib
+
+import (
+	"math"
+
+	"go-interpreter-practice/object"
+)
+
+func init() {
+	modules = append(modules, mathModule)
+}
+
+// mathModule は Go の math パッケージを onu の math モジュールにする
+func mathModule() *object.Module {
+	m := object.NewModule("math")
+	m.Members["Abs"] = &object.Builtin{Name: "math.Abs", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Abs", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Abs", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Abs(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Acos"] = &object.Builtin{Name: "math.Acos", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Acos", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Acos", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Acos(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Acosh"] = &object.Builtin{Name: "math.Acosh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Acosh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Acosh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Acosh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Asin"] = &object.Builtin{Name: "math.Asin", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Asin", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Asin", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Asin(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Asinh"] = &object.Builtin{Name: "math.Asinh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Asinh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Asinh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Asinh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Atan"] = &object.Builtin{Name: "math.Atan", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Atan", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Atan", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Atan(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Atan2"] = &object.Builtin{Name: "math.Atan2", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Atan2", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Atan2", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Atan2", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Atan2(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Atanh"] = &object.Builtin{Name: "math.Atanh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Atanh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Atanh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Atanh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Cbrt"] = &object.Builtin{Name: "math.Cbrt", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Cbrt", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Cbrt", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Cbrt(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Ceil"] = &object.Builtin{Name: "math.Ceil", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Ceil", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Ceil", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Ceil(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Copysign"] = &object.Builtin{Name: "math.Copysign", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Copysign", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Copysign", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Copysign", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Copysign(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Cos"] = &object.Builtin{Name: "math.Cos", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Cos", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Cos", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Cos(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Cosh"] = &object.Builtin{Name: "math.Cosh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Cosh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Cosh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Cosh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Dim"] = &object.Builtin{Name: "math.Dim", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Dim", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Dim", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Dim", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Dim(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["E"] = object.NewFloat(float64(math.E))
+	m.Members["Erf"] = &object.Builtin{Name: "math.Erf", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Erf", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Erf", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Erf(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Erfc"] = &object.Builtin{Name: "math.Erfc", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Erfc", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Erfc", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Erfc(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Erfcinv"] = &object.Builtin{Name: "math.Erfcinv", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Erfcinv", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Erfcinv", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Erfcinv(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Erfinv"] = &object.Builtin{Name: "math.Erfinv", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Erfinv", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Erfinv", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Erfinv(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Exp"] = &object.Builtin{Name: "math.Exp", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Exp", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Exp", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Exp(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Exp2"] = &object.Builtin{Name: "math.Exp2", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Exp2", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Exp2", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Exp2(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Expm1"] = &object.Builtin{Name: "math.Expm1", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Expm1", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Expm1", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Expm1(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["FMA"] = &object.Builtin{Name: "math.FMA", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.FMA", args, 3); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.FMA", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.FMA", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argFloat("math.FMA", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := math.FMA(a0, a1, a2)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Float32bits"] = &object.Builtin{Name: "math.Float32bits", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Float32bits", args, 1); err != nil {
+			return err
+		}
+		var a0 float32
+		if err := argValue("math.Float32bits", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := math.Float32bits(a0)
+		return toObject("math.Float32bits", r0)
+	}}
+	m.Members["Float32frombits"] = &object.Builtin{Name: "math.Float32frombits", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Float32frombits", args, 1); err != nil {
+			return err
+		}
+		var a0 uint32
+		if err := argValue("math.Float32frombits", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := math.Float32frombits(a0)
+		return toObject("math.Float32frombits", r0)
+	}}
+	m.Members["Float64bits"] = &object.Builtin{Name: "math.Float64bits", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Float64bits", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Float64bits", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Float64bits(a0)
+		return toObject("math.Float64bits", r0)
+	}}
+	m.Members["Float64frombits"] = &object.Builtin{Name: "math.Float64frombits", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Float64frombits", args, 1); err != nil {
+			return err
+		}
+		var a0 uint64
+		if err := argValue("math.Float64frombits", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := math.Float64frombits(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Floor"] = &object.Builtin{Name: "math.Floor", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Floor", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Floor", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Floor(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Frexp"] = &object.Builtin{Name: "math.Frexp", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Frexp", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Frexp", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, r1 := math.Frexp(a0)
+		return toObjects("math.Frexp", r0, r1)
+	}}
+	m.Members["Gamma"] = &object.Builtin{Name: "math.Gamma", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Gamma", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Gamma", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Gamma(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Hypot"] = &object.Builtin{Name: "math.Hypot", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Hypot", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Hypot", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Hypot", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Hypot(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Ilogb"] = &object.Builtin{Name: "math.Ilogb", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Ilogb", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Ilogb", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Ilogb(a0)
+		return object.NewInteger(r0)
+	}}
+	m.Members["Inf"] = &object.Builtin{Name: "math.Inf", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Inf", args, 1); err != nil {
+			return err
+		}
+		a0, err := argInt("math.Inf", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Inf(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["IsInf"] = &object.Builtin{Name: "math.IsInf", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.IsInf", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.IsInf", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("math.IsInf", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.IsInf(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["IsNaN"] = &object.Builtin{Name: "math.IsNaN", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.IsNaN", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.IsNaN", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.IsNaN(a0)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["J0"] = &object.Builtin{Name: "math.J0", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.J0", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.J0", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.J0(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["J1"] = &object.Builtin{Name: "math.J1", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.J1", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.J1", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.J1(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Jn"] = &object.Builtin{Name: "math.Jn", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Jn", args, 2); err != nil {
+			return err
+		}
+		a0, err := argInt("math.Jn", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Jn", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Jn(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Ldexp"] = &object.Builtin{Name: "math.Ldexp", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Ldexp", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Ldexp", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("math.Ldexp", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Ldexp(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Lgamma"] = &object.Builtin{Name: "math.Lgamma", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Lgamma", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Lgamma", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, r1 := math.Lgamma(a0)
+		return toObjects("math.Lgamma", r0, r1)
+	}}
+	m.Members["Ln10"] = object.NewFloat(float64(math.Ln10))
+	m.Members["Ln2"] = object.NewFloat(float64(math.Ln2))
+	m.Members["Log"] = &object.Builtin{Name: "math.Log", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Log", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Log", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Log(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Log10"] = &object.Builtin{Name: "math.Log10", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Log10", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Log10", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Log10(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Log10E"] = object.NewFloat(float64(math.Log10E))
+	m.Members["Log1p"] = &object.Builtin{Name: "math.Log1p", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Log1p", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Log1p", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Log1p(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Log2"] = &object.Builtin{Name: "math.Log2", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Log2", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Log2", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Log2(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Log2E"] = object.NewFloat(float64(math.Log2E))
+	m.Members["Logb"] = &object.Builtin{Name: "math.Logb", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Logb", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Logb", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Logb(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Max"] = &object.Builtin{Name: "math.Max", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Max", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Max", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Max", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Max(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["MaxFloat32"] = object.NewFloat(float64(math.MaxFloat32))
+	m.Members["MaxFloat64"] = object.NewFloat(float64(math.MaxFloat64))
+	m.Members["MaxInt"] = object.NewInteger(int(math.MaxInt))
+	m.Members["MaxInt16"] = object.NewInteger(int(math.MaxInt16))
+	m.Members["MaxInt32"] = object.NewInteger(int(math.MaxInt32))
+	m.Members["MaxInt64"] = object.NewInteger(int(math.MaxInt64))
+	m.Members["MaxInt8"] = object.NewInteger(int(math.MaxInt8))
+	m.Members["MaxUint16"] = object.NewInteger(int(math.MaxUint16))
+	m.Members["MaxUint32"] = object.NewInteger(int(math.MaxUint32))
+	m.Members["MaxUint8"] = object.NewInteger(int(math.MaxUint8))
+	m.Members["Min"] = &object.Builtin{Name: "math.Min", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Min", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Min", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Min", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Min(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["MinInt"] = object.NewInteger(int(math.MinInt))
+	m.Members["MinInt16"] = object.NewInteger(int(math.MinInt16))
+	m.Members["MinInt32"] = object.NewInteger(int(math.MinInt32))
+	m.Members["MinInt64"] = object.NewInteger(int(math.MinInt64))
+	m.Members["MinInt8"] = object.NewInteger(int(math.MinInt8))
+	m.Members["Mod"] = &object.Builtin{Name: "math.Mod", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Mod", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Mod", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Mod", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Mod(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Modf"] = &object.Builtin{Name: "math.Modf", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Modf", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Modf", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, r1 := math.Modf(a0)
+		return toObjects("math.Modf", r0, r1)
+	}}
+	m.Members["NaN"] = &object.Builtin{Name: "math.NaN", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.NaN", args, 0); err != nil {
+			return err
+		}
+		r0 := math.NaN()
+		return object.NewFloat(r0)
+	}}
+	m.Members["Nextafter"] = &object.Builtin{Name: "math.Nextafter", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Nextafter", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Nextafter", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Nextafter", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Nextafter(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Nextafter32"] = &object.Builtin{Name: "math.Nextafter32", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Nextafter32", args, 2); err != nil {
+			return err
+		}
+		var a0 float32
+		if err := argValue("math.Nextafter32", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 float32
+		if err := argValue("math.Nextafter32", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := math.Nextafter32(a0, a1)
+		return toObject("math.Nextafter32", r0)
+	}}
+	m.Members["Phi"] = object.NewFloat(float64(math.Phi))
+	m.Members["Pi"] = object.NewFloat(float64(math.Pi))
+	m.Members["Pow"] = &object.Builtin{Name: "math.Pow", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Pow", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Pow", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Pow", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Pow(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Pow10"] = &object.Builtin{Name: "math.Pow10", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Pow10", args, 1); err != nil {
+			return err
+		}
+		a0, err := argInt("math.Pow10", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Pow10(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Remainder"] = &object.Builtin{Name: "math.Remainder", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Remainder", args, 2); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Remainder", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Remainder", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Remainder(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Round"] = &object.Builtin{Name: "math.Round", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Round", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Round", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Round(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["RoundToEven"] = &object.Builtin{Name: "math.RoundToEven", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.RoundToEven", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.RoundToEven", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.RoundToEven(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Signbit"] = &object.Builtin{Name: "math.Signbit", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Signbit", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Signbit", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Signbit(a0)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["Sin"] = &object.Builtin{Name: "math.Sin", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Sin", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Sin", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Sin(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Sincos"] = &object.Builtin{Name: "math.Sincos", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Sincos", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Sincos", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, r1 := math.Sincos(a0)
+		return toObjects("math.Sincos", r0, r1)
+	}}
+	m.Members["Sinh"] = &object.Builtin{Name: "math.Sinh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Sinh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Sinh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Sinh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["SmallestNonzeroFloat32"] = object.NewFloat(float64(math.SmallestNonzeroFloat32))
+	m.Members["SmallestNonzeroFloat64"] = object.NewFloat(float64(math.SmallestNonzeroFloat64))
+	m.Members["Sqrt"] = &object.Builtin{Name: "math.Sqrt", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Sqrt", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Sqrt", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Sqrt(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Sqrt2"] = object.NewFloat(float64(math.Sqrt2))
+	m.Members["SqrtE"] = object.NewFloat(float64(math.SqrtE))
+	m.Members["SqrtPhi"] = object.NewFloat(float64(math.SqrtPhi))
+	m.Members["SqrtPi"] = object.NewFloat(float64(math.SqrtPi))
+	m.Members["Tan"] = &object.Builtin{Name: "math.Tan", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Tan", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Tan", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Tan(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Tanh"] = &object.Builtin{Name: "math.Tanh", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Tanh", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Tanh", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Tanh(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Trunc"] = &object.Builtin{Name: "math.Trunc", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Trunc", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Trunc", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Trunc(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Y0"] = &object.Builtin{Name: "math.Y0", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Y0", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Y0", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Y0(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Y1"] = &object.Builtin{Name: "math.Y1", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Y1", args, 1); err != nil {
+			return err
+		}
+		a0, err := argFloat("math.Y1", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := math.Y1(a0)
+		return object.NewFloat(r0)
+	}}
+	m.Members["Yn"] = &object.Builtin{Name: "math.Yn", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("math.Yn", args, 2); err != nil {
+			return err
+		}
+		a0, err := argInt("math.Yn", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argFloat("math.Yn", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := math.Yn(a0, a1)
+		return object.NewFloat(r0)
+	}}
+	return m
+}
+
+// 変換できない型や値を使うため、次の関数と定数は登録していない
+//   - math.MaxUint
+//   - math.MaxUint64
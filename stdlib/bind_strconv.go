@@ -0,0 +1,542 @@
+// Code generated by "onu bind strconv"; DO NOT EDIT.
+
+package stdlib
+
+import (
+	"strconv"
+
+	"go-interpreter-practice/object"
+)
+
+func init() {
+	modules = append(modules, strconvModule)
+}
+
+// strconvModule は Go の strconv パッケージを onu の strconv モジュールにする
+func strconvModule() *object.Module {
+	m := object.NewModule("strconv")
+	m.Members["AppendBool"] = &object.Builtin{Name: "strconv.AppendBool", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendBool", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendBool", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argBool("strconv.AppendBool", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendBool(a0, a1)
+		return toObject("strconv.AppendBool", r0)
+	}}
+	m.Members["AppendFloat"] = &object.Builtin{Name: "strconv.AppendFloat", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendFloat", args, 5); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendFloat", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argFloat("strconv.AppendFloat", args, 1)
+		if err != nil {
+			return err
+		}
+		var a2 byte
+		if err := argValue("strconv.AppendFloat", args, 2, &a2); err != nil {
+			return err
+		}
+		a3, err := argInt("strconv.AppendFloat", args, 3)
+		if err != nil {
+			return err
+		}
+		a4, err := argInt("strconv.AppendFloat", args, 4)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendFloat(a0, a1, a2, a3, a4)
+		return toObject("strconv.AppendFloat", r0)
+	}}
+	m.Members["AppendInt"] = &object.Builtin{Name: "strconv.AppendInt", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendInt", args, 3); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendInt", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 int64
+		if err := argValue("strconv.AppendInt", args, 1, &a1); err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.AppendInt", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendInt(a0, a1, a2)
+		return toObject("strconv.AppendInt", r0)
+	}}
+	m.Members["AppendQuote"] = &object.Builtin{Name: "strconv.AppendQuote", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuote", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuote", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strconv.AppendQuote", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuote(a0, a1)
+		return toObject("strconv.AppendQuote", r0)
+	}}
+	m.Members["AppendQuoteRune"] = &object.Builtin{Name: "strconv.AppendQuoteRune", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuoteRune", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuoteRune", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 rune
+		if err := argValue("strconv.AppendQuoteRune", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuoteRune(a0, a1)
+		return toObject("strconv.AppendQuoteRune", r0)
+	}}
+	m.Members["AppendQuoteRuneToASCII"] = &object.Builtin{Name: "strconv.AppendQuoteRuneToASCII", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuoteRuneToASCII", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuoteRuneToASCII", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 rune
+		if err := argValue("strconv.AppendQuoteRuneToASCII", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuoteRuneToASCII(a0, a1)
+		return toObject("strconv.AppendQuoteRuneToASCII", r0)
+	}}
+	m.Members["AppendQuoteRuneToGraphic"] = &object.Builtin{Name: "strconv.AppendQuoteRuneToGraphic", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuoteRuneToGraphic", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuoteRuneToGraphic", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 rune
+		if err := argValue("strconv.AppendQuoteRuneToGraphic", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuoteRuneToGraphic(a0, a1)
+		return toObject("strconv.AppendQuoteRuneToGraphic", r0)
+	}}
+	m.Members["AppendQuoteToASCII"] = &object.Builtin{Name: "strconv.AppendQuoteToASCII", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuoteToASCII", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuoteToASCII", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strconv.AppendQuoteToASCII", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuoteToASCII(a0, a1)
+		return toObject("strconv.AppendQuoteToASCII", r0)
+	}}
+	m.Members["AppendQuoteToGraphic"] = &object.Builtin{Name: "strconv.AppendQuoteToGraphic", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendQuoteToGraphic", args, 2); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendQuoteToGraphic", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strconv.AppendQuoteToGraphic", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendQuoteToGraphic(a0, a1)
+		return toObject("strconv.AppendQuoteToGraphic", r0)
+	}}
+	m.Members["AppendUint"] = &object.Builtin{Name: "strconv.AppendUint", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.AppendUint", args, 3); err != nil {
+			return err
+		}
+		var a0 []byte
+		if err := argValue("strconv.AppendUint", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 uint64
+		if err := argValue("strconv.AppendUint", args, 1, &a1); err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.AppendUint", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.AppendUint(a0, a1, a2)
+		return toObject("strconv.AppendUint", r0)
+	}}
+	m.Members["Atoi"] = &object.Builtin{Name: "strconv.Atoi", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.Atoi", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.Atoi", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.Atoi(a0)
+		if goErr != nil {
+			return goError("strconv.Atoi", goErr)
+		}
+		return object.NewInteger(r0)
+	}}
+	m.Members["CanBackquote"] = &object.Builtin{Name: "strconv.CanBackquote", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.CanBackquote", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.CanBackquote", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.CanBackquote(a0)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["FormatBool"] = &object.Builtin{Name: "strconv.FormatBool", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.FormatBool", args, 1); err != nil {
+			return err
+		}
+		a0, err := argBool("strconv.FormatBool", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.FormatBool(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["FormatComplex"] = &object.Builtin{Name: "strconv.FormatComplex", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.FormatComplex", args, 4); err != nil {
+			return err
+		}
+		var a0 complex128
+		if err := argValue("strconv.FormatComplex", args, 0, &a0); err != nil {
+			return err
+		}
+		var a1 byte
+		if err := argValue("strconv.FormatComplex", args, 1, &a1); err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.FormatComplex", args, 2)
+		if err != nil {
+			return err
+		}
+		a3, err := argInt("strconv.FormatComplex", args, 3)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.FormatComplex(a0, a1, a2, a3)
+		return object.NewString(r0)
+	}}
+	m.Members["FormatFloat"] = &object.Builtin{Name: "strconv.FormatFloat", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.FormatFloat", args, 4); err != nil {
+			return err
+		}
+		a0, err := argFloat("strconv.FormatFloat", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 byte
+		if err := argValue("strconv.FormatFloat", args, 1, &a1); err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.FormatFloat", args, 2)
+		if err != nil {
+			return err
+		}
+		a3, err := argInt("strconv.FormatFloat", args, 3)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.FormatFloat(a0, a1, a2, a3)
+		return object.NewString(r0)
+	}}
+	m.Members["FormatInt"] = &object.Builtin{Name: "strconv.FormatInt", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.FormatInt", args, 2); err != nil {
+			return err
+		}
+		var a0 int64
+		if err := argValue("strconv.FormatInt", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.FormatInt", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.FormatInt(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["FormatUint"] = &object.Builtin{Name: "strconv.FormatUint", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.FormatUint", args, 2); err != nil {
+			return err
+		}
+		var a0 uint64
+		if err := argValue("strconv.FormatUint", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.FormatUint", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.FormatUint(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["IntSize"] = object.NewInteger(int(strconv.IntSize))
+	m.Members["IsGraphic"] = &object.Builtin{Name: "strconv.IsGraphic", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.IsGraphic", args, 1); err != nil {
+			return err
+		}
+		var a0 rune
+		if err := argValue("strconv.IsGraphic", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := strconv.IsGraphic(a0)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["IsPrint"] = &object.Builtin{Name: "strconv.IsPrint", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.IsPrint", args, 1); err != nil {
+			return err
+		}
+		var a0 rune
+		if err := argValue("strconv.IsPrint", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := strconv.IsPrint(a0)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["Itoa"] = &object.Builtin{Name: "strconv.Itoa", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.Itoa", args, 1); err != nil {
+			return err
+		}
+		a0, err := argInt("strconv.Itoa", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.Itoa(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["NumError"] = newType("strconv.NumError", func() interface{} { return new(strconv.NumError) })
+	m.Members["ParseBool"] = &object.Builtin{Name: "strconv.ParseBool", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.ParseBool", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.ParseBool", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.ParseBool(a0)
+		if goErr != nil {
+			return goError("strconv.ParseBool", goErr)
+		}
+		return object.NewBoolean(r0)
+	}}
+	m.Members["ParseComplex"] = &object.Builtin{Name: "strconv.ParseComplex", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.ParseComplex", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.ParseComplex", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.ParseComplex", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.ParseComplex(a0, a1)
+		if goErr != nil {
+			return goError("strconv.ParseComplex", goErr)
+		}
+		return toObject("strconv.ParseComplex", r0)
+	}}
+	m.Members["ParseFloat"] = &object.Builtin{Name: "strconv.ParseFloat", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.ParseFloat", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.ParseFloat", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.ParseFloat", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.ParseFloat(a0, a1)
+		if goErr != nil {
+			return goError("strconv.ParseFloat", goErr)
+		}
+		return object.NewFloat(r0)
+	}}
+	m.Members["ParseInt"] = &object.Builtin{Name: "strconv.ParseInt", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.ParseInt", args, 3); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.ParseInt", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.ParseInt", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.ParseInt", args, 2)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.ParseInt(a0, a1, a2)
+		if goErr != nil {
+			return goError("strconv.ParseInt", goErr)
+		}
+		return toObject("strconv.ParseInt", r0)
+	}}
+	m.Members["ParseUint"] = &object.Builtin{Name: "strconv.ParseUint", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.ParseUint", args, 3); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.ParseUint", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("strconv.ParseUint", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argInt("strconv.ParseUint", args, 2)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.ParseUint(a0, a1, a2)
+		if goErr != nil {
+			return goError("strconv.ParseUint", goErr)
+		}
+		return toObject("strconv.ParseUint", r0)
+	}}
+	m.Members["Quote"] = &object.Builtin{Name: "strconv.Quote", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.Quote", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.Quote", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.Quote(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuoteRune"] = &object.Builtin{Name: "strconv.QuoteRune", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuoteRune", args, 1); err != nil {
+			return err
+		}
+		var a0 rune
+		if err := argValue("strconv.QuoteRune", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := strconv.QuoteRune(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuoteRuneToASCII"] = &object.Builtin{Name: "strconv.QuoteRuneToASCII", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuoteRuneToASCII", args, 1); err != nil {
+			return err
+		}
+		var a0 rune
+		if err := argValue("strconv.QuoteRuneToASCII", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := strconv.QuoteRuneToASCII(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuoteRuneToGraphic"] = &object.Builtin{Name: "strconv.QuoteRuneToGraphic", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuoteRuneToGraphic", args, 1); err != nil {
+			return err
+		}
+		var a0 rune
+		if err := argValue("strconv.QuoteRuneToGraphic", args, 0, &a0); err != nil {
+			return err
+		}
+		r0 := strconv.QuoteRuneToGraphic(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuoteToASCII"] = &object.Builtin{Name: "strconv.QuoteToASCII", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuoteToASCII", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.QuoteToASCII", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.QuoteToASCII(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuoteToGraphic"] = &object.Builtin{Name: "strconv.QuoteToGraphic", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuoteToGraphic", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.QuoteToGraphic", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strconv.QuoteToGraphic(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["QuotedPrefix"] = &object.Builtin{Name: "strconv.QuotedPrefix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.QuotedPrefix", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.QuotedPrefix", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.QuotedPrefix(a0)
+		if goErr != nil {
+			return goError("strconv.QuotedPrefix", goErr)
+		}
+		return object.NewString(r0)
+	}}
+	m.Members["Unquote"] = &object.Builtin{Name: "strconv.Unquote", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.Unquote", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.Unquote", args, 0)
+		if err != nil {
+			return err
+		}
+		r0, goErr := strconv.Unquote(a0)
+		if goErr != nil {
+			return goError("strconv.Unquote", goErr)
+		}
+		return object.NewString(r0)
+	}}
+	m.Members["UnquoteChar"] = &object.Builtin{Name: "strconv.UnquoteChar", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strconv.UnquoteChar", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strconv.UnquoteChar", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 byte
+		if err := argValue("strconv.UnquoteChar", args, 1, &a1); err != nil {
+			return err
+		}
+		r0, r1, r2, goErr := strconv.UnquoteChar(a0, a1)
+		if goErr != nil {
+			return goError("strconv.UnquoteChar", goErr)
+		}
+		return toObjects("strconv.UnquoteChar", r0, r1, r2)
+	}}
+	return m
+}
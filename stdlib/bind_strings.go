@@ -0,0 +1,699 @@
+// Code generated by "onu bind strings"; DO NOT EDIT.
+
+package stdlib
+
+import (
+	"strings"
+	"unicode"
+
+	"go-interpreter-practice/object"
+)
+
+func init() {
+	modules = append(modules, stringsModule)
+}
+
+// stringsModule は Go の strings パッケージを onu の strings モジュールにする
+func stringsModule() *object.Module {
+	m := object.NewModule("strings")
+	m.Members["Builder"] = newType("strings.Builder", func() interface{} { return new(strings.Builder) })
+	m.Members["Clone"] = &object.Builtin{Name: "strings.Clone", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Clone", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Clone", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Clone(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["Compare"] = &object.Builtin{Name: "strings.Compare", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Compare", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Compare", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Compare", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Compare(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["Contains"] = &object.Builtin{Name: "strings.Contains", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Contains", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Contains", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Contains", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Contains(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["ContainsAny"] = &object.Builtin{Name: "strings.ContainsAny", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ContainsAny", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ContainsAny", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.ContainsAny", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ContainsAny(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["ContainsRune"] = &object.Builtin{Name: "strings.ContainsRune", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ContainsRune", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ContainsRune", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 rune
+		if err := argValue("strings.ContainsRune", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strings.ContainsRune(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["Count"] = &object.Builtin{Name: "strings.Count", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Count", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Count", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Count", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Count(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["Cut"] = &object.Builtin{Name: "strings.Cut", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Cut", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Cut", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Cut", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, r1, r2 := strings.Cut(a0, a1)
+		return toObjects("strings.Cut", r0, r1, r2)
+	}}
+	m.Members["CutLast"] = &object.Builtin{Name: "strings.CutLast", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.CutLast", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.CutLast", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.CutLast", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, r1, r2 := strings.CutLast(a0, a1)
+		return toObjects("strings.CutLast", r0, r1, r2)
+	}}
+	m.Members["CutPrefix"] = &object.Builtin{Name: "strings.CutPrefix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.CutPrefix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.CutPrefix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.CutPrefix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, r1 := strings.CutPrefix(a0, a1)
+		return toObjects("strings.CutPrefix", r0, r1)
+	}}
+	m.Members["CutSuffix"] = &object.Builtin{Name: "strings.CutSuffix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.CutSuffix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.CutSuffix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.CutSuffix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0, r1 := strings.CutSuffix(a0, a1)
+		return toObjects("strings.CutSuffix", r0, r1)
+	}}
+	m.Members["EqualFold"] = &object.Builtin{Name: "strings.EqualFold", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.EqualFold", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.EqualFold", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.EqualFold", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.EqualFold(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["Fields"] = &object.Builtin{Name: "strings.Fields", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Fields", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Fields", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Fields(a0)
+		return toObject("strings.Fields", r0)
+	}}
+	m.Members["HasPrefix"] = &object.Builtin{Name: "strings.HasPrefix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.HasPrefix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.HasPrefix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.HasPrefix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.HasPrefix(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["HasSuffix"] = &object.Builtin{Name: "strings.HasSuffix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.HasSuffix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.HasSuffix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.HasSuffix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.HasSuffix(a0, a1)
+		return object.NewBoolean(r0)
+	}}
+	m.Members["Index"] = &object.Builtin{Name: "strings.Index", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Index", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Index", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Index", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Index(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["IndexAny"] = &object.Builtin{Name: "strings.IndexAny", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.IndexAny", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.IndexAny", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.IndexAny", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.IndexAny(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["IndexByte"] = &object.Builtin{Name: "strings.IndexByte", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.IndexByte", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.IndexByte", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 byte
+		if err := argValue("strings.IndexByte", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strings.IndexByte(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["IndexRune"] = &object.Builtin{Name: "strings.IndexRune", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.IndexRune", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.IndexRune", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 rune
+		if err := argValue("strings.IndexRune", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strings.IndexRune(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["Join"] = &object.Builtin{Name: "strings.Join", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Join", args, 2); err != nil {
+			return err
+		}
+		var a0 []string
+		if err := argValue("strings.Join", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strings.Join", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Join(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["LastIndex"] = &object.Builtin{Name: "strings.LastIndex", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.LastIndex", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.LastIndex", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.LastIndex", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.LastIndex(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["LastIndexAny"] = &object.Builtin{Name: "strings.LastIndexAny", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.LastIndexAny", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.LastIndexAny", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.LastIndexAny", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.LastIndexAny(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["LastIndexByte"] = &object.Builtin{Name: "strings.LastIndexByte", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.LastIndexByte", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.LastIndexByte", args, 0)
+		if err != nil {
+			return err
+		}
+		var a1 byte
+		if err := argValue("strings.LastIndexByte", args, 1, &a1); err != nil {
+			return err
+		}
+		r0 := strings.LastIndexByte(a0, a1)
+		return object.NewInteger(r0)
+	}}
+	m.Members["NewReader"] = &object.Builtin{Name: "strings.NewReader", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.NewReader", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.NewReader", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.NewReader(a0)
+		return toObject("strings.NewReader", r0)
+	}}
+	m.Members["NewReplacer"] = &object.Builtin{Name: "strings.NewReplacer", Fn: func(args ...object.Object) object.Object {
+		if err := checkVariadicArity("strings.NewReplacer", args, 0); err != nil {
+			return err
+		}
+		a0 := make([]string, len(args)-0)
+		for i := range a0 {
+			if err := argValue("strings.NewReplacer", args, 0+i, &a0[i]); err != nil {
+				return err
+			}
+		}
+		r0 := strings.NewReplacer(a0...)
+		return toObject("strings.NewReplacer", r0)
+	}}
+	m.Members["Reader"] = newType("strings.Reader", func() interface{} { return new(strings.Reader) })
+	m.Members["Repeat"] = &object.Builtin{Name: "strings.Repeat", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Repeat", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Repeat", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argInt("strings.Repeat", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Repeat(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["Replace"] = &object.Builtin{Name: "strings.Replace", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Replace", args, 4); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Replace", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Replace", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argString("strings.Replace", args, 2)
+		if err != nil {
+			return err
+		}
+		a3, err := argInt("strings.Replace", args, 3)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Replace(a0, a1, a2, a3)
+		return object.NewString(r0)
+	}}
+	m.Members["ReplaceAll"] = &object.Builtin{Name: "strings.ReplaceAll", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ReplaceAll", args, 3); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ReplaceAll", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.ReplaceAll", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argString("strings.ReplaceAll", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ReplaceAll(a0, a1, a2)
+		return object.NewString(r0)
+	}}
+	m.Members["Replacer"] = newType("strings.Replacer", func() interface{} { return new(strings.Replacer) })
+	m.Members["Split"] = &object.Builtin{Name: "strings.Split", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Split", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Split", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Split", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Split(a0, a1)
+		return toObject("strings.Split", r0)
+	}}
+	m.Members["SplitAfter"] = &object.Builtin{Name: "strings.SplitAfter", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.SplitAfter", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.SplitAfter", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.SplitAfter", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.SplitAfter(a0, a1)
+		return toObject("strings.SplitAfter", r0)
+	}}
+	m.Members["SplitAfterN"] = &object.Builtin{Name: "strings.SplitAfterN", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.SplitAfterN", args, 3); err != nil {
+			return err
+		}
+		a0, err := argString("strings.SplitAfterN", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.SplitAfterN", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argInt("strings.SplitAfterN", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := strings.SplitAfterN(a0, a1, a2)
+		return toObject("strings.SplitAfterN", r0)
+	}}
+	m.Members["SplitN"] = &object.Builtin{Name: "strings.SplitN", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.SplitN", args, 3); err != nil {
+			return err
+		}
+		a0, err := argString("strings.SplitN", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.SplitN", args, 1)
+		if err != nil {
+			return err
+		}
+		a2, err := argInt("strings.SplitN", args, 2)
+		if err != nil {
+			return err
+		}
+		r0 := strings.SplitN(a0, a1, a2)
+		return toObject("strings.SplitN", r0)
+	}}
+	m.Members["Title"] = &object.Builtin{Name: "strings.Title", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Title", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Title", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Title(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["ToLower"] = &object.Builtin{Name: "strings.ToLower", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToLower", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ToLower", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToLower(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["ToLowerSpecial"] = &object.Builtin{Name: "strings.ToLowerSpecial", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToLowerSpecial", args, 2); err != nil {
+			return err
+		}
+		var a0 unicode.SpecialCase
+		if err := argValue("strings.ToLowerSpecial", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strings.ToLowerSpecial", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToLowerSpecial(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["ToTitle"] = &object.Builtin{Name: "strings.ToTitle", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToTitle", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ToTitle", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToTitle(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["ToTitleSpecial"] = &object.Builtin{Name: "strings.ToTitleSpecial", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToTitleSpecial", args, 2); err != nil {
+			return err
+		}
+		var a0 unicode.SpecialCase
+		if err := argValue("strings.ToTitleSpecial", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strings.ToTitleSpecial", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToTitleSpecial(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["ToUpper"] = &object.Builtin{Name: "strings.ToUpper", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToUpper", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ToUpper", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToUpper(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["ToUpperSpecial"] = &object.Builtin{Name: "strings.ToUpperSpecial", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToUpperSpecial", args, 2); err != nil {
+			return err
+		}
+		var a0 unicode.SpecialCase
+		if err := argValue("strings.ToUpperSpecial", args, 0, &a0); err != nil {
+			return err
+		}
+		a1, err := argString("strings.ToUpperSpecial", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToUpperSpecial(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["ToValidUTF8"] = &object.Builtin{Name: "strings.ToValidUTF8", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.ToValidUTF8", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.ToValidUTF8", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.ToValidUTF8", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.ToValidUTF8(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["Trim"] = &object.Builtin{Name: "strings.Trim", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.Trim", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.Trim", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.Trim", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.Trim(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["TrimLeft"] = &object.Builtin{Name: "strings.TrimLeft", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.TrimLeft", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.TrimLeft", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.TrimLeft", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.TrimLeft(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["TrimPrefix"] = &object.Builtin{Name: "strings.TrimPrefix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.TrimPrefix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.TrimPrefix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.TrimPrefix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.TrimPrefix(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["TrimRight"] = &object.Builtin{Name: "strings.TrimRight", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.TrimRight", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.TrimRight", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.TrimRight", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.TrimRight(a0, a1)
+		return object.NewString(r0)
+	}}
+	m.Members["TrimSpace"] = &object.Builtin{Name: "strings.TrimSpace", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.TrimSpace", args, 1); err != nil {
+			return err
+		}
+		a0, err := argString("strings.TrimSpace", args, 0)
+		if err != nil {
+			return err
+		}
+		r0 := strings.TrimSpace(a0)
+		return object.NewString(r0)
+	}}
+	m.Members["TrimSuffix"] = &object.Builtin{Name: "strings.TrimSuffix", Fn: func(args ...object.Object) object.Object {
+		if err := checkArity("strings.TrimSuffix", args, 2); err != nil {
+			return err
+		}
+		a0, err := argString("strings.TrimSuffix", args, 0)
+		if err != nil {
+			return err
+		}
+		a1, err := argString("strings.TrimSuffix", args, 1)
+		if err != nil {
+			return err
+		}
+		r0 := strings.TrimSuffix(a0, a1)
+		return object.NewString(r0)
+	}}
+	return m
+}
+
+// 変換できない型や値を使うため、次の関数と定数は登録していない
+//   - strings.ContainsFunc
+//   - strings.FieldsFunc
+//   - strings.FieldsFuncSeq
+//   - strings.FieldsSeq
+//   - strings.IndexFunc
+//   - strings.LastIndexFunc
+//   - strings.Lines
+//   - strings.Map
+//   - strings.SplitAfterSeq
+//   - strings.SplitSeq
+//   - strings.TrimFunc
+//   - strings.TrimLeftFunc
+//   - strings.TrimRightFunc
@@ -0,0 +1,153 @@
+// Package stdlib は Go の標準パッケージを onu のモジュールとして提供する
+// bind_*.go は onu bind で生成したもので、手で編集しない
+package stdlib
+
+//go:generate go run .. bind -o bind_math.go math
+//go:generate go run .. bind -o bind_strconv.go strconv
+//go:generate go run .. bind -o bind_strings.go strings
+
+import (
+	"strings"
+
+	"go-interpreter-practice/object"
+	"go-interpreter-practice/onu"
+)
+
+// 生成したコードの init で追加される
+var modules []func() *object.Module
+
+// Register はすべてのモジュールを interpreter に登録する
+// スクリプトからは strings.ToUpper("x") のように呼び出せる
+func Register(interpreter *onu.Interpreter) {
+	for _, module := range Modules() {
+		interpreter.RegisterModule(module)
+	}
+}
+
+// Modules はすべてのモジュールを新しく作って返す
+func Modules() []*object.Module {
+	result := make([]*object.Module, len(modules))
+	for i, module := range modules {
+		m := module()
+		for _, member := range m.Members {
+			if builtin, ok := member.(*object.Builtin); ok {
+				builtin.Fn = recoverPanic(builtin.Name, builtin.Fn)
+			}
+		}
+		result[i] = m
+	}
+	return result
+}
+
+// recoverPanic は Go の関数の panic を実行時エラーに変える
+// 不正な引数で panic する関数(strconv.FormatInt など)があるため
+func recoverPanic(name string, fn object.BuiltinFunction) object.BuiltinFunction {
+	return func(args ...object.Object) (result object.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = object.NewError("%s: panic: %v", name, r)
+			}
+		}()
+		return fn(args...)
+	}
+}
+
+func checkArity(name string, args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return object.NewError("wrong number of arguments: %s expects %d, got %d", name, want, len(args))
+	}
+	return nil
+}
+
+func checkVariadicArity(name string, args []object.Object, min int) *object.Error {
+	if len(args) < min {
+		return object.NewError("wrong number of arguments: %s expects at least %d, got %d", name, min, len(args))
+	}
+	return nil
+}
+
+func argString(name string, args []object.Object, i int) (string, *object.Error) {
+	s, ok := args[i].(*object.String)
+	if !ok {
+		return "", argTypeError(name, i, args[i], "string")
+	}
+	return s.Value, nil
+}
+
+func argBool(name string, args []object.Object, i int) (bool, *object.Error) {
+	b, ok := args[i].(*object.Boolean)
+	if !ok {
+		return false, argTypeError(name, i, args[i], "bool")
+	}
+	return b.Value, nil
+}
+
+func argInt(name string, args []object.Object, i int) (int, *object.Error) {
+	n, ok := args[i].(*object.Integer)
+	if !ok {
+		return 0, argTypeError(name, i, args[i], "int")
+	}
+	return n.Value, nil
+}
+
+// 整数も float64 の引数として受け付ける
+func argFloat(name string, args []object.Object, i int) (float64, *object.Error) {
+	switch n := args[i].(type) {
+	case *object.Float:
+		return n.Value, nil
+	case *object.Integer:
+		return float64(n.Value), nil
+	}
+	return 0, argTypeError(name, i, args[i], "float64")
+}
+
+// argValue はそれ以外の型の引数を FromObject で変換する
+func argValue(name string, args []object.Object, i int, ptr interface{}) *object.Error {
+	if err := onu.FromObject(args[i], ptr); err != nil {
+		return object.NewError("%s: argument %d: %v", name, i+1, err)
+	}
+	return nil
+}
+
+func argTypeError(name string, i int, arg object.Object, want string) *object.Error {
+	return object.NewError("%s: argument %d: cannot use %s as %s", name, i+1, arg.Type(), want)
+}
+
+// strconv のエラーのように、すでに関数名で始まるメッセージには名前を付け足さない
+func goError(name string, err error) *object.Error {
+	if strings.HasPrefix(err.Error(), name+":") {
+		return object.NewError("%v", err)
+	}
+	return object.NewError("%s: %v", name, err)
+}
+
+func toObject(name string, v interface{}) object.Object {
+	obj, err := onu.ToObject(v)
+	if err != nil {
+		return object.NewError("%s: %v", name, err)
+	}
+	return obj
+}
+
+// 複数の戻り値は配列にする
+func toObjects(name string, values ...interface{}) object.Object {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		element := toObject(name, v)
+		if _, ok := element.(*object.Error); ok {
+			return element
+		}
+		elements[i] = element
+	}
+	return object.NewArray(elements)
+}
+
+// newType は構造体型のゼロ値へのポインタを作る組み込み関数を返す
+func newType(name string, zero func() interface{}) *object.Builtin {
+	return &object.Builtin{Name: name, Fn: func(args ...object.Object) object.Object {
+		if err := checkArity(name, args, 0); err != nil {
+			return err
+		}
+		return toObject(name, zero())
+	}}
+}
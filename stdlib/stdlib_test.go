@@ -0,0 +1,86 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/onu"
+)
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`strings.ToUpper("abc")`, "ABC"},
+		{`strings.Split("a,b", ",")`, `["a", "b"]`},
+		{`strings.Repeat("ab", 2)`, "abab"},
+		{`strings.NewReplacer("a", "1", "b", "2").Replace("abc")`, "12c"},
+		{`var b = strings.Builder()
+b.WriteString("x")
+b.String()`, "x"},
+		{`strconv.Itoa(42)`, "42"},
+		{`strconv.ParseInt("ff", 16, 64)`, "255"},
+		{`strconv.Quote("a")`, `"a"`},
+		{`math.Sqrt(16)`, "4"},
+		{`math.Max(1, 2.5)`, "2.5"},
+		{`math.MaxInt8`, "127"},
+		{`var strings = 1
+strings`, "1"},
+	}
+	for _, tt := range tests {
+		i := onu.New()
+		Register(i)
+		result, err := i.Run(context.Background(), "test.onu", tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if result.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, result, tt.expected)
+		}
+	}
+}
+
+// モジュールの関数が作った値も生成の上限に数える
+func TestModulesCountAllocations(t *testing.T) {
+	for _, backend := range []onu.Backend{onu.TreeWalker, onu.BytecodeVM} {
+		i := onu.New()
+		i.SetBackend(backend)
+		i.SetLimits(evaluator.Limits{MaxAllocations: 1000000})
+		Register(i)
+		_, err := i.Run(context.Background(), "test.onu", `strings.Repeat("x", 10000000)`)
+		var runtimeErr *onu.RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "allocation limit exceeded (max 1000000 objects)" {
+			t.Errorf("backend %v: got %v", backend, err)
+		}
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`strconv.Atoi("x")`, `strconv.Atoi: parsing "x": invalid syntax`},
+		{`strings.ToUpper(1)`, "strings.ToUpper: argument 1: cannot use INTEGER as string"},
+		{`strings.ToUpper()`, "wrong number of arguments: strings.ToUpper expects 1, got 0"},
+		{`strconv.FormatInt(1, 300)`, "strconv.FormatInt: panic: strconv: illegal AppendInt/FormatInt base"},
+		{`math.Pi = 3`, "cannot assign to math.Pi"},
+	}
+	for _, tt := range tests {
+		i := onu.New()
+		Register(i)
+		_, err := i.Run(context.Background(), "test.onu", tt.input)
+		var runtimeErr *onu.RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%s: expected RuntimeError, got %v", tt.input, err)
+			continue
+		}
+		if runtimeErr.Err.Message != tt.message {
+			t.Errorf("%s: message = %q, want %q", tt.input, runtimeErr.Err.Message, tt.message)
+		}
+	}
+}
@@ -3,18 +3,23 @@ package token
 type TokenType string
 
 const (
-	LEFT_PAREN  TokenType = "LEFT_PAREN"  // (
-	RIGHT_PAREN TokenType = "RIGHT_PAREN" // )
-	LEFT_BRACE  TokenType = "LEFT_BRACE"  // {
-	RIGHT_BRACE TokenType = "RIGHT_BRACE" // }
-	COMMA       TokenType = "COMMA"       // ,
-	DOT         TokenType = "DOT"         // .
-	MINUS       TokenType = "MINUS"       // -
-	PLUS        TokenType = "PLUS"        // +
-	SEMICOLON   TokenType = "SEMICOLON"   // ;
-	SLASH       TokenType = "SLASH"       // /
-	STAR        TokenType = "STAR"        // *
-	LINE_BREAK  TokenType = "LINE_BREAK"  // \n
+	LEFT_PAREN    TokenType = "LEFT_PAREN"    // (
+	RIGHT_PAREN   TokenType = "RIGHT_PAREN"   // )
+	LEFT_BRACE    TokenType = "LEFT_BRACE"    // {
+	RIGHT_BRACE   TokenType = "RIGHT_BRACE"   // }
+	LEFT_BRACKET  TokenType = "LEFT_BRACKET"  // [
+	RIGHT_BRACKET TokenType = "RIGHT_BRACKET" // ]
+	COMMA         TokenType = "COMMA"         // ,
+	COLON         TokenType = "COLON"         // :
+	DOT           TokenType = "DOT"           // .
+	ELLIPSIS      TokenType = "ELLIPSIS"      // ...
+	MINUS         TokenType = "MINUS"         // -
+	PLUS          TokenType = "PLUS"          // +
+	SEMICOLON     TokenType = "SEMICOLON"     // ;
+	SLASH         TokenType = "SLASH"         // /
+	STAR          TokenType = "STAR"          // *
+	PERCENT       TokenType = "PERCENT"       // %
+	LINE_BREAK    TokenType = "LINE_BREAK"    // \n
 
 	BANG          TokenType = "BANG"          // !
 	NOT_EQUAL     TokenType = "NOT_EQUAL"     // !=
@@ -37,6 +42,7 @@ const (
 	FUN    TokenType = "FUN"    // fun
 	FOR    TokenType = "FOR"    // for
 	IF     TokenType = "IF"     // if
+	IN     TokenType = "IN"     // in
 	NIL    TokenType = "NIL"    // nil
 	OR     TokenType = "OR"     // or
 	PRINT  TokenType = "PRINT"  // print
@@ -0,0 +1,77 @@
+package vm
+
+import (
+	"go-interpreter-practice/compiler"
+	"go-interpreter-practice/object"
+)
+
+// Closure は CompiledFunction と、それが捕まえた外側の変数の組
+// スクリプトからは評価器の Function と同じく関数として見える
+type Closure struct {
+	Fn       *compiler.CompiledFunction
+	Upvalues []*Upvalue
+}
+
+func (c *Closure) Type() object.ObjectType {
+	return object.FUNCTION
+}
+
+func (c *Closure) String() string {
+	return c.Fn.String()
+}
+
+func (c *Closure) IsTruthy() bool {
+	return true
+}
+
+// BindMethod は this を instance に束縛したメソッドを返す
+func (c *Closure) BindMethod(instance *object.Instance) object.Object {
+	return &BoundMethod{Receiver: instance, Method: c}
+}
+
+// BoundMethod は this を束縛したメソッド
+// 呼び出すと Receiver がフレームの slot 0 に入る
+type BoundMethod struct {
+	Receiver *object.Instance
+	Method   *Closure
+}
+
+func (b *BoundMethod) Type() object.ObjectType {
+	return object.FUNCTION
+}
+
+func (b *BoundMethod) String() string {
+	return b.Method.String()
+}
+
+func (b *BoundMethod) IsTruthy() bool {
+	return true
+}
+
+// Upvalue はクロージャが捕まえた変数
+// 変数を宣言したフレームが生きている間はスタックの index 番目を指し(open)、
+// スコープを抜けると値を自分に移して閉じる
+type Upvalue struct {
+	index int
+	value object.Object
+	open  bool
+}
+
+// iterator は for 文で取り出す要素の一覧
+// ループの間だけスタックに置かれ、スクリプトからは見えない
+type iterator struct {
+	elements []object.Object
+	next     int
+}
+
+func (it *iterator) Type() object.ObjectType {
+	return "ITERATOR"
+}
+
+func (it *iterator) String() string {
+	return "iterator"
+}
+
+func (it *iterator) IsTruthy() bool {
+	return true
+}
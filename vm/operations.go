@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"go-interpreter-practice/compiler"
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+)
+
+var operators = [...]string{
+	compiler.OpAdd:          "+",
+	compiler.OpSub:          "-",
+	compiler.OpMul:          "*",
+	compiler.OpDiv:          "/",
+	compiler.OpMod:          "%",
+	compiler.OpEqual:        "==",
+	compiler.OpNotEqual:     "!=",
+	compiler.OpLess:         "<",
+	compiler.OpLessEqual:    "<=",
+	compiler.OpGreater:      ">",
+	compiler.OpGreaterEqual: ">=",
+}
+
+// binaryOperation は二項演算の結果を返す
+// よく使う整数同士の演算はここで計算し、それ以外(オーバーフローやエラーを含む)は評価器に任せる
+func binaryOperation(op compiler.Opcode, left, right object.Object) object.Object {
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			a, b := l.Value, r.Value
+			switch op {
+			case compiler.OpAdd:
+				if sum := a + b; (sum > a) == (b > 0) {
+					return object.NewInteger(sum)
+				}
+			case compiler.OpSub:
+				if difference := a - b; (difference < a) == (b > 0) {
+					return object.NewInteger(difference)
+				}
+			case compiler.OpEqual:
+				return object.NewBoolean(a == b)
+			case compiler.OpNotEqual:
+				return object.NewBoolean(a != b)
+			case compiler.OpLess:
+				return object.NewBoolean(a < b)
+			case compiler.OpLessEqual:
+				return object.NewBoolean(a <= b)
+			case compiler.OpGreater:
+				return object.NewBoolean(a > b)
+			case compiler.OpGreaterEqual:
+				return object.NewBoolean(a >= b)
+			}
+		}
+	}
+	return evaluator.InfixOperation(operators[op], left, right)
+}
@@ -0,0 +1,678 @@
+// Package vm は compiler が作ったバイトコードをスタックマシンで実行する
+//
+// 組み込み関数、実行上限、出力先は評価器(evaluator.Evaluator)のものを共有し、
+// 演算も評価器と同じ実装を使うので、どちらで実行しても結果とエラーメッセージは同じになる
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"go-interpreter-practice/compiler"
+	"go-interpreter-practice/evaluator"
+	"go-interpreter-practice/object"
+)
+
+const initialStackSize = 1024
+
+type VM struct {
+	host    *evaluator.Evaluator // 組み込み関数、実行上限、出力先
+	globals *object.Environment
+
+	stack        []object.Object
+	sp           int // 次に積む位置
+	frames       []frame
+	depth        int        // 関数のフレームの数(トップレベルは数えない)
+	openUpvalues []*Upvalue // まだ閉じていない upvalue(index の昇順)
+}
+
+// frame は実行中の関数
+// スタックの base 番目から順に slot 0、引数、ローカル変数が並び、その上を式の計算に使う
+type frame struct {
+	closure *Closure
+	ip      int // 次に実行する命令の位置
+	base    int
+	main    bool // トップレベルの文を実行しているフレーム
+	host    bool // ホストの Go コードから呼び出されたフレーム(スタックトレースに含めない)
+}
+
+// New は host の組み込み関数と出力先を使い、globals をグローバル環境にする VM を作る
+func New(host *evaluator.Evaluator, globals *object.Environment) *VM {
+	return &VM{
+		host:    host,
+		globals: globals,
+		stack:   make([]object.Object, initialStackSize),
+	}
+}
+
+// Run はプログラムを実行し、最後の式文の値を返す
+func (vm *VM) Run(bytecode *compiler.Bytecode) object.Object {
+	return vm.RunContext(context.Background(), bytecode, evaluator.Limits{})
+}
+
+// RunContext は ctx がキャンセルされるか limits のどれかに達すると実行を止めてエラーを返す
+// ステップ数は実行した命令の数で数える
+func (vm *VM) RunContext(ctx context.Context, bytecode *compiler.Bytecode, limits evaluator.Limits) object.Object {
+	defer vm.host.WithContext(ctx, limits)()
+	stop := len(vm.frames)
+	main := &Closure{Fn: bytecode.Main}
+	base := vm.sp
+	defer vm.unwindOnPanic(stop, base)
+	vm.push(main)
+	vm.setupLocals(main.Fn, base, 0)
+	vm.frames = append(vm.frames, frame{closure: main, base: base, main: true})
+	return vm.run(stop)
+}
+
+// CallContext はホストの Go コードからスクリプトの関数や組み込み関数を呼び出す
+func (vm *VM) CallContext(ctx context.Context, fn object.Object, args []object.Object, limits evaluator.Limits) object.Object {
+	defer vm.host.WithContext(ctx, limits)()
+	stop := len(vm.frames)
+	base := vm.sp
+	defer vm.unwindOnPanic(stop, base)
+	vm.push(fn)
+	for _, arg := range args {
+		vm.push(arg)
+	}
+	if err := vm.callValue(len(args), true); err != nil {
+		if err.Stack == nil {
+			vm.attachStackTrace(err, 0)
+		}
+		vm.sp = base
+		return err
+	}
+	if len(vm.frames) == stop {
+		// 組み込み関数や init のないクラスはその場で値が返る
+		result := vm.stack[vm.sp-1]
+		vm.sp = base
+		return result
+	}
+	return vm.run(stop)
+}
+
+// unwindOnPanic は panic で抜けるときにフレームとスタックを呼び出し前に戻してから panic を続ける
+// 呼び出し元で recover したあとも同じ VM を使えるようにする
+func (vm *VM) unwindOnPanic(stop, base int) {
+	if r := recover(); r != nil {
+		vm.frames = vm.frames[:stop]
+		vm.sp = base
+		panic(r)
+	}
+}
+
+// run はフレームの数が stop に戻るまで命令を実行し、最後に返された値を返す
+func (vm *VM) run(stop int) object.Object {
+	f := &vm.frames[len(vm.frames)-1]
+	ins := f.closure.Fn.Instructions
+	constants := f.closure.Fn.Constants
+	for {
+		op := compiler.Opcode(ins[f.ip])
+		f.ip++
+		if err := vm.host.Step(); err != nil {
+			return vm.fail(err, stop)
+		}
+
+		switch op {
+		case compiler.OpConstant:
+			vm.push(constants[read16(ins, f.ip)])
+			f.ip += 2
+		case compiler.OpNil:
+			vm.push(object.NewNil())
+		case compiler.OpTrue:
+			vm.push(object.NewBoolean(true))
+		case compiler.OpFalse:
+			vm.push(object.NewBoolean(false))
+		case compiler.OpPop:
+			vm.sp--
+
+		case compiler.OpAdd, compiler.OpSub, compiler.OpMul, compiler.OpDiv, compiler.OpMod,
+			compiler.OpEqual, compiler.OpNotEqual,
+			compiler.OpLess, compiler.OpLessEqual, compiler.OpGreater, compiler.OpGreaterEqual:
+			result := binaryOperation(op, vm.stack[vm.sp-2], vm.stack[vm.sp-1])
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, stop)
+			}
+			if err := vm.host.AllocateObject(result); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.sp--
+			vm.stack[vm.sp-1] = result
+		case compiler.OpMinus, compiler.OpNot:
+			operator := "-"
+			if op == compiler.OpNot {
+				operator = "!"
+			}
+			result := evaluator.PrefixOperation(operator, vm.stack[vm.sp-1])
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, stop)
+			}
+			if err := vm.host.AllocateObject(result); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.stack[vm.sp-1] = result
+
+		case compiler.OpJump:
+			f.ip = read16(ins, f.ip)
+		case compiler.OpJumpIfFalse:
+			vm.sp--
+			if vm.stack[vm.sp].IsTruthy() {
+				f.ip += 2
+			} else {
+				f.ip = read16(ins, f.ip)
+			}
+		case compiler.OpJumpIfProvided:
+			if vm.stack[f.base+read16(ins, f.ip)] != nil {
+				f.ip = read16(ins, f.ip+2)
+			} else {
+				f.ip += 4
+			}
+
+		case compiler.OpGetGlobal:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			value, ok := vm.globals.Get(name)
+			if !ok {
+				// 変数が見つからなければ組み込み関数とモジュールを探す
+				if value, ok = vm.host.Builtin(name); !ok {
+					return vm.fail(object.NewError("undefined identifier %v", name), stop)
+				}
+			}
+			vm.push(value)
+		case compiler.OpSetGlobal:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			if !vm.globals.Assign(name, vm.stack[vm.sp-1]) {
+				return vm.fail(object.NewError("undefined identifier %v", name), stop)
+			}
+		case compiler.OpDefineGlobal:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			if vm.globals.HasOwn(name) {
+				err := object.NewError("%s is already declared in this scope (line %d)", name, vm.currentLine())
+				return vm.fail(err, stop)
+			}
+			vm.sp--
+			vm.globals.Set(name, vm.stack[vm.sp])
+		case compiler.OpGetLocal:
+			vm.push(vm.stack[f.base+read16(ins, f.ip)])
+			f.ip += 2
+		case compiler.OpSetLocal:
+			vm.stack[f.base+read16(ins, f.ip)] = vm.stack[vm.sp-1]
+			f.ip += 2
+		case compiler.OpGetUpvalue:
+			index := read16(ins, f.ip)
+			f.ip += 2
+			value := f.closure.Upvalues[index].get(vm.stack)
+			if value == nil {
+				// 宣言される前に呼び出されたクロージャ
+				err := object.NewError("undefined identifier %v", f.closure.Fn.Upvalues[index].Name)
+				return vm.fail(err, stop)
+			}
+			vm.push(value)
+		case compiler.OpSetUpvalue:
+			f.closure.Upvalues[read16(ins, f.ip)].set(vm.stack, vm.stack[vm.sp-1])
+			f.ip += 2
+		case compiler.OpCloseUpvalues:
+			vm.closeUpvalues(f.base + read16(ins, f.ip))
+			f.ip += 2
+
+		case compiler.OpArray:
+			n := read16(ins, f.ip)
+			f.ip += 2
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[vm.sp-n:vm.sp])
+			vm.sp -= n
+			if err := vm.host.Allocate(1 + n); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.push(object.NewArray(elements))
+		case compiler.OpHash:
+			n := read16(ins, f.ip)
+			f.ip += 2
+			hash := object.NewHash()
+			for i := vm.sp - 2*n; i < vm.sp; i += 2 {
+				key, ok := vm.stack[i].(object.Hashable)
+				if !ok {
+					return vm.fail(object.NewError("unusable as hash key: %s", vm.stack[i].Type()), stop)
+				}
+				hash.Set(key, vm.stack[i+1])
+			}
+			vm.sp -= 2 * n
+			if err := vm.host.AllocateObject(hash); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.push(hash)
+		case compiler.OpIndex:
+			result := evaluator.IndexOperation(vm.stack[vm.sp-2], vm.stack[vm.sp-1])
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, stop)
+			}
+			vm.sp--
+			vm.stack[vm.sp-1] = result
+		case compiler.OpSetIndex:
+			if err := evaluator.IndexAssignment(vm.stack[vm.sp-2], vm.stack[vm.sp-1], vm.stack[vm.sp-3]); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.sp -= 2
+		case compiler.OpGetProperty:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			result := evaluator.GetProperty(vm.stack[vm.sp-1], name)
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, stop)
+			}
+			vm.stack[vm.sp-1] = result
+		case compiler.OpSetProperty:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			if err := evaluator.SetProperty(vm.stack[vm.sp-1], name, vm.stack[vm.sp-2]); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.sp--
+		case compiler.OpGetSuper:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			superclass := vm.stack[vm.sp-1].(*object.Class)
+			this := vm.stack[vm.sp-2].(*object.Instance)
+			method, ok := superclass.FindMethod(name)
+			if !ok {
+				return vm.fail(object.NewError("undefined property %s", name), stop)
+			}
+			vm.sp--
+			vm.stack[vm.sp-1] = method.BindMethod(this)
+
+		case compiler.OpCall:
+			argc := read16(ins, f.ip)
+			f.ip += 2
+			if err := vm.callValue(argc, false); err != nil {
+				return vm.fail(err, stop)
+			}
+			f = &vm.frames[len(vm.frames)-1]
+			ins = f.closure.Fn.Instructions
+			constants = f.closure.Fn.Constants
+		case compiler.OpTailCall:
+			argc := read16(ins, f.ip)
+			f.ip += 2
+			if err := vm.tailCall(f, argc); err != nil {
+				return vm.fail(err, stop)
+			}
+			f = &vm.frames[len(vm.frames)-1]
+			ins = f.closure.Fn.Instructions
+			constants = f.closure.Fn.Constants
+		case compiler.OpReturn:
+			result := vm.stack[vm.sp-1]
+			vm.closeUpvalues(f.base)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if !f.main {
+				vm.depth--
+			}
+			if len(vm.frames) == stop {
+				vm.sp = f.base
+				return result
+			}
+			// 呼び出された関数の位置に戻り値を置く
+			vm.stack[f.base] = result
+			vm.sp = f.base + 1
+			f = &vm.frames[len(vm.frames)-1]
+			ins = f.closure.Fn.Instructions
+			constants = f.closure.Fn.Constants
+		case compiler.OpClosure:
+			fn := constants[read16(ins, f.ip)].(*compiler.CompiledFunction)
+			f.ip += 2
+			upvalues := make([]*Upvalue, len(fn.Upvalues))
+			for i, upvalue := range fn.Upvalues {
+				if upvalue.Local {
+					upvalues[i] = vm.captureUpvalue(f.base + upvalue.Index)
+				} else {
+					upvalues[i] = f.closure.Upvalues[upvalue.Index]
+				}
+			}
+			if err := vm.host.Allocate(1); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.push(&Closure{Fn: fn, Upvalues: upvalues})
+		case compiler.OpClass:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			hasSuperclass := ins[f.ip+2] == 1
+			f.ip += 3
+			class := &object.Class{Name: name, Methods: make(map[string]object.Method)}
+			if hasSuperclass {
+				vm.sp--
+				superclass, ok := vm.stack[vm.sp].(*object.Class)
+				if !ok {
+					err := object.NewError("superclass must be a class: %s", vm.stack[vm.sp].Type())
+					return vm.fail(err, stop)
+				}
+				class.Superclass = superclass
+			}
+			vm.push(class)
+		case compiler.OpMethod:
+			name := constants[read16(ins, f.ip)].(*object.String).Value
+			f.ip += 2
+			vm.sp--
+			class := vm.stack[vm.sp-1].(*object.Class)
+			class.Methods[name] = vm.stack[vm.sp].(*Closure)
+
+		case compiler.OpIter:
+			var elements []object.Object
+			switch iterable := vm.stack[vm.sp-1].(type) {
+			case *object.Hash:
+				// ループ中にキーが追加されても影響しないように、先にキーを取り出しておく
+				elements = iterable.Keys()
+			case *object.Array:
+				elements = append(elements, iterable.Elements...)
+			default:
+				return vm.fail(object.NewError("not iterable: %s", iterable.Type()), stop)
+			}
+			vm.stack[vm.sp-1] = &iterator{elements: elements}
+		case compiler.OpIterNext:
+			it := vm.stack[vm.sp-1].(*iterator)
+			if it.next >= len(it.elements) {
+				vm.sp--
+				f.ip = read16(ins, f.ip+2)
+				break
+			}
+			if err := vm.host.CheckContext(); err != nil {
+				return vm.fail(err, stop)
+			}
+			vm.stack[f.base+read16(ins, f.ip)] = it.elements[it.next]
+			it.next++
+			f.ip += 4
+		case compiler.OpPrint:
+			vm.sp--
+			if err := vm.host.WriteOutput(vm.stack[vm.sp].String() + "\n"); err != nil {
+				return vm.fail(err, stop)
+			}
+
+		default:
+			return vm.fail(object.NewError("unknown opcode %d", op), stop)
+		}
+	}
+}
+
+func read16(ins compiler.Instructions, ip int) int {
+	return int(ins[ip])<<8 | int(ins[ip+1])
+}
+
+func (vm *VM) push(obj object.Object) {
+	if vm.sp == len(vm.stack) {
+		vm.grow(vm.sp + 1)
+	}
+	vm.stack[vm.sp] = obj
+	vm.sp++
+}
+
+// grow はスタックを n 個以上の値が入る大きさにする
+// upvalue はスタック上の位置で変数を指すので、作り直しても影響しない
+func (vm *VM) grow(n int) {
+	size := 2 * len(vm.stack)
+	if size < n {
+		size = n
+	}
+	stack := make([]object.Object, size)
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+// callValue はスタックに積まれた関数と argc 個の引数で呼び出す
+// スクリプトの関数なら新しいフレームを積み、それ以外はその場で結果を積む
+func (vm *VM) callValue(argc int, fromHost bool) *object.Error {
+	base := vm.sp - argc - 1
+	switch callee := vm.stack[base].(type) {
+	case *Closure:
+		return vm.callClosure(callee, base, argc, fromHost)
+	case *BoundMethod:
+		vm.stack[base] = callee.Receiver
+		return vm.callClosure(callee.Method, base, argc, fromHost)
+	case *object.Class:
+		return vm.instantiate(callee, base, argc, fromHost)
+	case *object.Builtin:
+		if err := vm.host.CheckContext(); err != nil {
+			return err
+		}
+		args := make([]object.Object, argc)
+		copy(args, vm.stack[base+1:vm.sp])
+		result := callee.Fn(args...)
+		if err, ok := result.(*object.Error); ok {
+			return err
+		}
+		if result == nil {
+			result = object.NewNil()
+		} else if err := vm.host.AllocateObject(result); err != nil {
+			// 組み込み関数が作った値も上限に数える
+			return err
+		}
+		vm.stack[base] = result
+		vm.sp = base + 1
+		return nil
+	}
+	return object.NewError("not a function %v", vm.stack[base].Type())
+}
+
+func (vm *VM) callClosure(closure *Closure, base, argc int, fromHost bool) *object.Error {
+	if err := vm.checkArity(closure.Fn, argc, fromHost); err != nil {
+		return err
+	}
+	if vm.depth >= vm.host.MaxCallDepth() {
+		return object.NewError("maximum recursion depth exceeded (%d)", vm.host.MaxCallDepth())
+	}
+	if err := vm.host.CheckContext(); err != nil {
+		return err
+	}
+	if err := vm.host.Allocate(1); err != nil {
+		return err
+	}
+	vm.setupLocals(closure.Fn, base, argc)
+	vm.frames = append(vm.frames, frame{closure: closure, base: base, host: fromHost})
+	vm.depth++
+	return nil
+}
+
+// tailCall は関数の末尾位置での呼び出し
+// 呼び出す関数がスクリプトの関数なら今のフレームを置き換えるので、末尾再帰は呼び出しの深さを伸ばさない
+func (vm *VM) tailCall(f *frame, argc int) *object.Error {
+	base := vm.sp - argc - 1
+	var closure *Closure
+	switch callee := vm.stack[base].(type) {
+	case *Closure:
+		closure = callee
+	case *BoundMethod:
+		closure = callee.Method
+		vm.stack[base] = callee.Receiver
+	default:
+		// クラスや組み込み関数は普通に呼び出し、続く OpReturn で結果を返す
+		return vm.callValue(argc, false)
+	}
+	if err := vm.checkArity(closure.Fn, argc, false); err != nil {
+		return err
+	}
+	if err := vm.host.CheckContext(); err != nil {
+		return err
+	}
+	if err := vm.host.Allocate(1); err != nil {
+		return err
+	}
+	vm.closeUpvalues(f.base)
+	copy(vm.stack[f.base:], vm.stack[base:vm.sp])
+	vm.setupLocals(closure.Fn, f.base, argc)
+	f.closure = closure
+	f.ip = 0
+	return nil
+}
+
+// クラスを呼び出すとインスタンスを作り、init があれば実行する
+// init はインスタンスを slot 0 に置いて呼び出し、常にインスタンスを返す
+func (vm *VM) instantiate(class *object.Class, base, argc int, fromHost bool) *object.Error {
+	if err := vm.host.Allocate(1); err != nil {
+		return err
+	}
+	instance := object.NewInstance(class)
+	initializer, ok := class.FindMethod("init")
+	if !ok {
+		if argc != 0 {
+			return object.NewError("wrong number of arguments: %s expects 0, got %d (called at line %d)", class.Name, argc, vm.callLine(fromHost))
+		}
+		vm.stack[base] = instance
+		vm.sp = base + 1
+		return nil
+	}
+	closure, ok := initializer.(*Closure)
+	if !ok {
+		return object.NewError("not a function %v", initializer.Type())
+	}
+	vm.stack[base] = instance
+	return vm.callClosure(closure, base, argc, fromHost)
+}
+
+func (vm *VM) checkArity(fn *compiler.CompiledFunction, got int, fromHost bool) *object.Error {
+	min, max := fn.MinArity, fn.NumParams
+	if got >= min && (got <= max || fn.HasRest) {
+		return nil
+	}
+	var expected string
+	switch {
+	case fn.HasRest:
+		expected = fmt.Sprintf("at least %d", min)
+	case min == max:
+		expected = fmt.Sprintf("%d", min)
+	default:
+		expected = fmt.Sprintf("%d to %d", min, max)
+	}
+	return object.NewError("wrong number of arguments: %s expects %s, got %d (called at line %d)", fn.DisplayName(), expected, got, vm.callLine(fromHost))
+}
+
+// setupLocals は base から始まるフレームの引数とローカル変数を用意する
+// 省略された引数とまだ宣言されていない変数は nil(Go の nil)にしておく
+func (vm *VM) setupLocals(fn *compiler.CompiledFunction, base, argc int) {
+	params := fn.NumParams
+	var rest *object.Array
+	if fn.HasRest {
+		elements := []object.Object{}
+		if argc > params {
+			elements = append(elements, vm.stack[base+1+params:base+1+argc]...)
+		}
+		rest = object.NewArray(elements)
+	}
+	if base+fn.NumLocals > len(vm.stack) {
+		vm.grow(base + fn.NumLocals)
+	}
+	provided := argc
+	if provided > params {
+		provided = params
+	}
+	for i := base + 1 + provided; i < base+fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+	if rest != nil {
+		vm.stack[base+1+params] = rest
+	}
+	vm.sp = base + fn.NumLocals
+}
+
+// captureUpvalue はスタックの index 番目の変数を指す upvalue を返す
+// 同じ変数を捕まえるクロージャは同じ upvalue を共有する
+func (vm *VM) captureUpvalue(index int) *Upvalue {
+	i := len(vm.openUpvalues)
+	for i > 0 && vm.openUpvalues[i-1].index > index {
+		i--
+	}
+	if i > 0 && vm.openUpvalues[i-1].index == index {
+		return vm.openUpvalues[i-1]
+	}
+	upvalue := &Upvalue{index: index, open: true}
+	vm.openUpvalues = append(vm.openUpvalues, nil)
+	copy(vm.openUpvalues[i+1:], vm.openUpvalues[i:])
+	vm.openUpvalues[i] = upvalue
+	return upvalue
+}
+
+// closeUpvalues はスタックの from 番目以降の変数を指す upvalue を閉じる
+func (vm *VM) closeUpvalues(from int) {
+	n := len(vm.openUpvalues)
+	for n > 0 && vm.openUpvalues[n-1].index >= from {
+		upvalue := vm.openUpvalues[n-1]
+		upvalue.value = vm.stack[upvalue.index]
+		upvalue.open = false
+		n--
+	}
+	vm.openUpvalues = vm.openUpvalues[:n]
+}
+
+func (u *Upvalue) get(stack []object.Object) object.Object {
+	if u.open {
+		return stack[u.index]
+	}
+	return u.value
+}
+
+func (u *Upvalue) set(stack []object.Object, value object.Object) {
+	if u.open {
+		stack[u.index] = value
+		return
+	}
+	u.value = value
+}
+
+// fail はエラーにスタックトレースを付け、stop より上のフレームを取り除く
+func (vm *VM) fail(err *object.Error, stop int) object.Object {
+	if err.Stack == nil {
+		vm.attachStackTrace(err, vm.currentLine())
+	}
+	if len(vm.frames) > stop {
+		base := vm.frames[stop].base
+		vm.closeUpvalues(base)
+		for _, f := range vm.frames[stop:] {
+			if !f.main {
+				vm.depth--
+			}
+		}
+		vm.frames = vm.frames[:stop]
+		vm.sp = base
+	}
+	return err
+}
+
+// 呼び出し中の関数の位置を外側から順に並べ、最後にエラーが起きた位置を加える
+// 評価器と同じく、ホストの Go コードからの呼び出しはトレースに含めない
+func (vm *VM) attachStackTrace(err *object.Error, line int) {
+	err.Line = line
+	caller := "<main>"
+	stack := make([]object.Frame, 0, len(vm.frames)+1)
+	for i, f := range vm.frames {
+		if f.main {
+			caller = "<main>"
+			continue
+		}
+		if !f.host && i > 0 {
+			stack = append(stack, object.Frame{Function: caller, Line: vm.lineOf(i - 1)})
+		}
+		caller = f.closure.Fn.DisplayName()
+	}
+	if line != 0 {
+		stack = append(stack, object.Frame{Function: caller, Line: line})
+	}
+	err.Stack = stack
+}
+
+// currentLine は実行中の命令の行番号を返す
+func (vm *VM) currentLine() int {
+	if len(vm.frames) == 0 {
+		return 0
+	}
+	return vm.lineOf(len(vm.frames) - 1)
+}
+
+// callLine はエラーメッセージ用の呼び出し元の行番号を返す
+func (vm *VM) callLine(fromHost bool) int {
+	if fromHost {
+		return 0
+	}
+	return vm.currentLine()
+}
+
+// lineOf は i 番目のフレームが実行中の命令の行番号を返す
+// ip は実行中の命令の次を指しているので、一つ前のバイトで調べる
+func (vm *VM) lineOf(i int) int {
+	f := vm.frames[i]
+	return compiler.LineAt(f.closure.Fn.Lines, f.ip-1)
+}